@@ -1,5 +1,7 @@
 package codebook
 
+import "strings"
+
 type Datasets struct {
 	Items []*Dataset `json:"items,omitempty"`
 }
@@ -17,6 +19,18 @@ type Codebook struct {
 	CodeBook []Dimension `json:"codebook"`
 }
 
+// GetDimension returns the codebook entry for the named dimension, matching the name case insensitively. Returns nil if the
+// dimension is not in the codebook.
+func (cb *Codebook) GetDimension(name string) *Dimension {
+	for i := range cb.CodeBook {
+		if strings.EqualFold(cb.CodeBook[i].Name, name) {
+			return &cb.CodeBook[i]
+		}
+	}
+
+	return nil
+}
+
 type Dimension struct {
 	Name         string   `json:"name"`
 	Codes        []string `json:"codes"`
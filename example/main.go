@@ -6,7 +6,6 @@ import (
 	"os"
 
 	"github.com/ONSdigital/dp-ftb-client-go/ftb"
-	dphttp "github.com/ONSdigital/dp-net/http"
 	"github.com/ONSdigital/log.go/log"
 )
 
@@ -19,7 +18,7 @@ func main() {
 }
 
 func run() error {
-	ftbCli := ftb.NewClient(fmt.Sprintf("http://%s:10100", os.Getenv("EC2_IP")), os.Getenv("AUTH_PROXY_TOKEN"), dphttp.DefaultClient)
+	ftbCli := ftb.NewClientWithOptions(fmt.Sprintf("http://%s:10100", os.Getenv("EC2_IP")), ftb.WithAuthToken(os.Getenv("AUTH_PROXY_TOKEN")))
 
 	q := ftb.Query{
 		DatasetName: "People",
@@ -0,0 +1,213 @@
+package ftb
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+// AllDatasets registers an alias that applies to every dataset.
+const AllDatasets = ""
+
+// AliasRegistry maps friendly or CMD dimension IDs, such as "age", to the FTB variable names of each dataset, such as
+// "AGE". Aliases are matched case insensitively and names without an alias are passed to FTB unchanged. An alias never
+// shadows an FTB variable: a name that exactly matches a registered variable, or that is written in upper case like
+// an FTB variable, is only resolved by an alias registered in exactly that form.
+type AliasRegistry struct {
+	mu      sync.RWMutex
+	aliases map[string]map[string]aliasEntry
+	// variables holds the FTB variables known for each dataset, from alias targets and registered codebooks.
+	variables map[string]map[string]bool
+}
+
+type aliasEntry struct {
+	alias    string
+	variable string
+}
+
+func NewAliasRegistry() *AliasRegistry {
+	return &AliasRegistry{
+		aliases:   make(map[string]map[string]aliasEntry, 0),
+		variables: make(map[string]map[string]bool, 0),
+	}
+}
+
+// LoadAliasRegistry reads a registry from JSON config mapping dataset name to alias to FTB variable, e.g.
+// {"People": {"age": "AGE", "country": "COUNTRY"}}. Aliases under the "" dataset apply to every dataset.
+func LoadAliasRegistry(r io.Reader) (*AliasRegistry, error) {
+	var config map[string]map[string]string
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return nil, err
+	}
+
+	registry := NewAliasRegistry()
+	for dataset, aliases := range config {
+		for alias, variable := range aliases {
+			registry.Register(dataset, alias, variable)
+		}
+	}
+
+	return registry, nil
+}
+
+func (r *AliasRegistry) Register(dataset, alias, variable string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.init(dataset)
+	r.aliases[dataset][strings.ToLower(alias)] = aliasEntry{alias: alias, variable: variable}
+	r.variables[dataset][variable] = true
+}
+
+// init creates the maps for the dataset. Must be called with the lock held.
+func (r *AliasRegistry) init(dataset string) {
+	if r.aliases[dataset] == nil {
+		r.aliases[dataset] = make(map[string]aliasEntry, 0)
+	}
+
+	if r.variables[dataset] == nil {
+		r.variables[dataset] = make(map[string]bool, 0)
+	}
+}
+
+// RegisterCodebook derives aliases for a dataset from its codebook, so each variable can be referred to by its name in
+// any case or by its label. Aliases already registered are not replaced.
+func (r *AliasRegistry) RegisterCodebook(dataset string, cb *codebook.Codebook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.init(dataset)
+
+	for _, d := range cb.CodeBook {
+		r.variables[dataset][d.Name] = true
+
+		for _, alias := range []string{d.Name, d.Label} {
+			key := strings.ToLower(alias)
+			if _, ok := r.aliases[dataset][key]; !ok && key != "" {
+				r.aliases[dataset][key] = aliasEntry{alias: alias, variable: d.Name}
+			}
+		}
+	}
+}
+
+// Resolve returns the FTB variable for the dimension name, or the name unchanged if it has no alias.
+func (r *AliasRegistry) Resolve(dataset, name string) string {
+	if r == nil {
+		return name
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	isVariable := r.variables[dataset][name] || r.variables[AllDatasets][name] || name == strings.ToUpper(name)
+	key := strings.ToLower(name)
+
+	for _, aliases := range []map[string]aliasEntry{r.aliases[dataset], r.aliases[AllDatasets]} {
+		entry, ok := aliases[key]
+		if !ok {
+			continue
+		}
+
+		if entry.alias == name || !isVariable {
+			return entry.variable
+		}
+	}
+
+	return name
+}
+
+// resolveQuery returns a copy of the query with dimension names replaced by their FTB variables, along with the names
+// needed to map results back to the names the caller used.
+func (r *AliasRegistry) resolveQuery(q Query) (Query, aliasNames) {
+	names := make(aliasNames, 0)
+	resolve := func(name string) string {
+		variable := r.Resolve(q.DatasetName, name)
+		if variable != name {
+			names[strings.ToUpper(variable)] = name
+		}
+		return variable
+	}
+
+	resolved := q
+	resolved.DimensionsOptions = make([]DimensionOptions, 0, len(q.DimensionsOptions))
+	for _, d := range q.DimensionsOptions {
+		d.Name = resolve(d.Name)
+		resolved.DimensionsOptions = append(resolved.DimensionsOptions, d)
+	}
+
+	if q.RootDimension != "" {
+		resolved.RootDimension = resolve(q.RootDimension)
+	}
+
+	if len(q.Totals) > 0 {
+		resolved.Totals = make([]string, 0, len(q.Totals))
+		for _, name := range q.Totals {
+			resolved.Totals = append(resolved.Totals, resolve(name))
+		}
+	}
+
+	return resolved, names
+}
+
+// aliasNames maps upper case FTB variable names back to the names used by the caller.
+type aliasNames map[string]string
+
+func (n aliasNames) restore(name string) string {
+	if original, ok := n[strings.ToUpper(name)]; ok {
+		return original
+	}
+
+	return name
+}
+
+// restoreQuery returns a copy of the query with its dimension names replaced by the names used by the caller.
+func (n aliasNames) restoreQuery(q Query) Query {
+	restored := q
+	restored.DimensionsOptions = make([]DimensionOptions, 0, len(q.DimensionsOptions))
+	for _, d := range q.DimensionsOptions {
+		d.Name = n.restore(d.Name)
+		restored.DimensionsOptions = append(restored.DimensionsOptions, d)
+	}
+
+	restored.RootDimension = n.restore(q.RootDimension)
+
+	if len(q.Totals) > 0 {
+		restored.Totals = make([]string, 0, len(q.Totals))
+		for _, name := range q.Totals {
+			restored.Totals = append(restored.Totals, n.restore(name))
+		}
+	}
+
+	return restored
+}
+
+// restoreCategories returns a copy of the categories named by the names used by the caller.
+func (n aliasNames) restoreCategories(categories []DimensionCategories) []DimensionCategories {
+	restored := make([]DimensionCategories, 0, len(categories))
+	for _, c := range categories {
+		c.Name = n.restore(c.Name)
+		restored = append(restored, c)
+	}
+
+	return restored
+}
+
+// restoreDimensions returns copies of the query options and codebook dimensions keyed by the names used by the caller,
+// so result rows are labelled with those names.
+func (n aliasNames) restoreDimensions(queryOptions []DimensionOptions, dimensions map[string]*codebook.Dimension) ([]DimensionOptions, map[string]*codebook.Dimension) {
+	options := make([]DimensionOptions, 0, len(queryOptions))
+	for _, d := range queryOptions {
+		d.Name = n.restore(d.Name)
+		options = append(options, d)
+	}
+
+	restored := make(map[string]*codebook.Dimension, len(dimensions))
+	for name, details := range dimensions {
+		restored[n.restore(name)] = details
+	}
+
+	return options, restored
+}
@@ -0,0 +1,121 @@
+package ftb
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+func TestAliasRegistryResolve(t *testing.T) {
+	registry, err := LoadAliasRegistry(strings.NewReader(`{"People": {"age": "AGE_2CATS", "Region": "REGION_CODE", "LA": "LAD"}, "": {"country": "COUNTRY"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	registry.RegisterCodebook("People", &codebook.Codebook{
+		CodeBook: []codebook.Dimension{
+			{Name: "Sex_Group", Label: "Sex"},
+			{Name: "OA", Label: "Output area"},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		dataset   string
+		dimension string
+		want      string
+	}{
+		{name: "alias", dataset: "People", dimension: "age", want: "AGE_2CATS"},
+		{name: "alias in another case", dataset: "People", dimension: "Age", want: "AGE_2CATS"},
+		{name: "FTB variable is not shadowed by an alias", dataset: "People", dimension: "AGE", want: "AGE"},
+		{name: "alias target passes through", dataset: "People", dimension: "AGE_2CATS", want: "AGE_2CATS"},
+		{name: "upper case alias registered in that form", dataset: "People", dimension: "LA", want: "LAD"},
+		{name: "upper case alias in another case", dataset: "People", dimension: "la", want: "LAD"},
+		{name: "codebook variable passes through", dataset: "People", dimension: "Sex_Group", want: "Sex_Group"},
+		{name: "codebook variable in another case", dataset: "People", dimension: "sex_group", want: "Sex_Group"},
+		{name: "codebook label", dataset: "People", dimension: "output area", want: "OA"},
+		{name: "alias for every dataset", dataset: "Households", dimension: "country", want: "COUNTRY"},
+		{name: "dataset alias does not apply to other datasets", dataset: "Households", dimension: "age", want: "age"},
+		{name: "unknown name passes through", dataset: "People", dimension: "tenure", want: "tenure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.Resolve(tt.dataset, tt.dimension); got != tt.want {
+				t.Errorf("Resolve(%q, %q) = %q, want %q", tt.dataset, tt.dimension, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilAliasRegistryResolve(t *testing.T) {
+	var registry *AliasRegistry
+	if got := registry.Resolve("People", "age"); got != "age" {
+		t.Errorf("Resolve() = %q, want %q", got, "age")
+	}
+}
+
+func TestResolvedNamesAreNotResolvedAgain(t *testing.T) {
+	f := newFakeFTB(t)
+	defer f.close()
+	f.blocked = func(dims []string) []int {
+		if len(dims) == 3 {
+			return []int{1}
+		}
+		return nil
+	}
+
+	// "COUNTRY" is itself an alias, so resolving the FTB variable a second time would query AGE instead.
+	registry := NewAliasRegistry()
+	registry.Register("People", "geo", "COUNTRY")
+	registry.Register("People", "COUNTRY", "AGE")
+	c := f.client(WithAliasRegistry(registry))
+
+	query := Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "geo"}, {Name: "SEX"}}, Limit: 10}
+
+	results := c.QueryMany(context.Background(), []Query{query}, QueryManyOptions{})
+	if err := results[0].Err; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantHeader := []string{"geo", "geo code", "SEX", "SEX code", "Observation"}
+	if header := results[0].Result.V4Table.Header; !reflect.DeepEqual(header, wantHeader) {
+		t.Errorf("header = %v, want %v", header, wantHeader)
+	}
+
+	if dim := results[0].Result.DisclosureControlDetails.Dimension; dim != "geo" {
+		t.Errorf("root dimension = %s, want geo", dim)
+	}
+
+	// Without the codebook the blocked options are looked up by index on the root dimension.
+	f.codebookStatus = http.StatusBadRequest
+	c = f.client(WithAliasRegistry(registry))
+
+	blocked := query
+	blocked.DimensionsOptions = append(blocked.DimensionsOptions, DimensionOptions{Name: "AGE"})
+	details, err := c.CheckDisclosure(context.Background(), blocked)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &DisclosureControlDetails{
+		Status:         StatusBlocked,
+		Dimension:      "geo",
+		BlockedOptions: []string{"W"},
+		BlockedCount:   1,
+		BlockedLabels:  []string{"Wales"},
+	}
+	if !reflect.DeepEqual(details, want) {
+		t.Errorf("CheckDisclosure() = %+v, want %+v", details, want)
+	}
+
+	for _, p := range f.paths() {
+		if strings.Contains(p, "dim=AGE&dim=SEX") || strings.HasPrefix(p, "/v6/query/People?dim=AGE") {
+			t.Errorf("resolved name was resolved again: %s", p)
+		}
+	}
+}
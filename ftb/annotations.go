@@ -0,0 +1,45 @@
+package ftb
+
+import "strings"
+
+// CellMarker flags a statistical property of an observation so outputs can show footnotes rather than treating every
+// cell as a plain count.
+type CellMarker string
+
+const (
+	MarkerBlocked        CellMarker = "blocked"
+	MarkerSuppressed     CellMarker = "suppressed"
+	MarkerPerturbed      CellMarker = "perturbed"
+	MarkerRounded        CellMarker = "rounded"
+	MarkerStructuralZero CellMarker = "zero-by-structure"
+)
+
+// Cell is an observation with any markers that apply to it.
+type Cell struct {
+	Value   int          `json:"value"`
+	Markers []CellMarker `json:"markers,omitempty"`
+}
+
+// CellAnnotator returns the markers for the observation of a row. The row holds the label and code of each dimension in
+// the V4 layout followed by the observation.
+type CellAnnotator func(row []string, value int) []CellMarker
+
+// HasMarker reports whether the marker applies to the cell.
+func (c Cell) HasMarker(marker CellMarker) bool {
+	for _, m := range c.Markers {
+		if m == marker {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c Cell) notes() string {
+	notes := make([]string, 0, len(c.Markers))
+	for _, m := range c.Markers {
+		notes = append(notes, string(m))
+	}
+
+	return strings.Join(notes, ", ")
+}
@@ -0,0 +1,101 @@
+package ftb
+
+import (
+	"context"
+)
+
+// QueryManyOptions configures a batch of queries run by QueryMany.
+type QueryManyOptions struct {
+	// Concurrency is the maximum number of queries run at once. Zero uses the client MaxConcurrency.
+	Concurrency int
+}
+
+// BatchResult holds the outcome of one query in a batch.
+type BatchResult struct {
+	Query  Query
+	Result *QueryResult
+	Err    error
+}
+
+// QueryMany runs the queries concurrently and returns a result for each in input order. A failed query does not stop
+// the rest of the batch. Dataset metadata and codebooks are looked up once per dataset and shared between queries
+// through the codebook cache.
+func (c *client) QueryMany(ctx context.Context, queries []Query, opts QueryManyOptions) []BatchResult {
+	results := make([]BatchResult, len(queries))
+	resolved := make([]Query, len(queries))
+	names := make([]aliasNames, len(queries))
+	for i, q := range queries {
+		results[i].Query = q
+		resolved[i], names[i] = c.Aliases.resolveQuery(q)
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = c.MaxConcurrency
+	}
+
+	prepared := c.prepareBatch(ctx, resolved, concurrency)
+
+	// Errors are recorded per query rather than returned so one failure does not cancel the rest of the batch.
+	err := forEach(ctx, len(prepared), concurrency, func(ctx context.Context, i int) error {
+		results[i].Result, results[i].Err = c.query(ctx, prepared[i], names[i])
+		return nil
+	})
+
+	if err != nil {
+		for i := range results {
+			if results[i].Result == nil && results[i].Err == nil {
+				results[i].Err = err
+			}
+		}
+	}
+
+	return results
+}
+
+// prepareBatch resolves the root dimension of each dataset once and warms the codebook cache with every dimension used
+// by the batch, fetching the codebooks of at most concurrency datasets at once. The queries must already be resolved to
+// FTB variables. Failures are ignored here so they are reported against the individual queries.
+func (c *client) prepareBatch(ctx context.Context, queries []Query, concurrency int) []Query {
+	prepared := make([]Query, len(queries))
+	copy(prepared, queries)
+
+	roots := make(map[string]string, 0)
+	datasets := make([]string, 0)
+	dims := make(map[string][]DimensionOptions, 0)
+	seen := make(map[string]map[string]bool, 0)
+
+	for i, q := range prepared {
+		if q.RootDimension == "" {
+			root, ok := roots[q.DatasetName]
+			if !ok {
+				root, _ = c.resolveRootDimension(ctx, q)
+				roots[q.DatasetName] = root
+			}
+			prepared[i].RootDimension = root
+		}
+
+		if seen[q.DatasetName] == nil {
+			seen[q.DatasetName] = make(map[string]bool, 0)
+			datasets = append(datasets, q.DatasetName)
+		}
+
+		for _, d := range q.DimensionsOptions {
+			if !seen[q.DatasetName][d.Name] {
+				seen[q.DatasetName][d.Name] = true
+				dims[q.DatasetName] = append(dims[q.DatasetName], DimensionOptions{Name: d.Name})
+			}
+		}
+	}
+
+	if c.codebookCache == nil {
+		return prepared
+	}
+
+	forEach(ctx, len(datasets), concurrency, func(ctx context.Context, i int) error {
+		c.getDimensionDetails(ctx, datasets[i], "", dims[datasets[i]])
+		return nil
+	})
+
+	return prepared
+}
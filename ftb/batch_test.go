@@ -0,0 +1,118 @@
+package ftb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+func TestQueryMany(t *testing.T) {
+	f := newFakeFTB(t)
+	defer f.close()
+
+	queries := []Query{
+		{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}}, Limit: 10},
+		{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "RELIGION"}}, Limit: 10},
+		{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "AGE", Options: []string{"2"}}}, Limit: 10},
+		{DatasetName: "Households", DimensionsOptions: []DimensionOptions{{Name: "SEX"}}, Limit: 10},
+	}
+
+	tests := []struct {
+		wantRows [][]string
+		wantErr  error
+	}{
+		{wantRows: [][]string{{"Male", "1", "18"}, {"Female", "2", "36"}}},
+		{wantErr: ErrNotFound},
+		{wantRows: [][]string{{"Age 2", "2", "27"}}},
+		{wantErr: ErrNotFound},
+	}
+
+	results := f.client().QueryMany(context.Background(), queries, QueryManyOptions{Concurrency: 2})
+	if len(results) != len(queries) {
+		t.Fatalf("results = %d, want %d", len(results), len(queries))
+	}
+
+	for i, tt := range tests {
+		if !reflect.DeepEqual(results[i].Query, queries[i]) {
+			t.Errorf("results[%d].Query = %+v, want %+v", i, results[i].Query, queries[i])
+		}
+
+		if tt.wantErr != nil {
+			if !errors.Is(results[i].Err, tt.wantErr) {
+				t.Errorf("results[%d].Err = %v, want %v", i, results[i].Err, tt.wantErr)
+			}
+			continue
+		}
+
+		if results[i].Err != nil {
+			t.Errorf("results[%d]: unexpected error: %v", i, results[i].Err)
+			continue
+		}
+
+		if !reflect.DeepEqual(results[i].Result.V4Table.Rows, tt.wantRows) {
+			t.Errorf("results[%d] rows = %v, want %v", i, results[i].Result.V4Table.Rows, tt.wantRows)
+		}
+	}
+}
+
+func TestQueryManyWarmUpConcurrency(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v6/codebook/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		cb := codebook.Codebook{Dataset: codebook.Dataset{Name: strings.TrimPrefix(r.URL.Path, "/v6/codebook/"), Digest: "d1"}}
+		for _, v := range r.URL.Query()["var"] {
+			cb.CodeBook = append(cb.CodeBook, codebook.Dimension{Name: v, Codes: []string{"1"}, Labels: []string{"One"}})
+		}
+		json.NewEncoder(w).Encode(cb)
+	}))
+	defer srv.Close()
+
+	queries := make([]Query, 6)
+	for i := range queries {
+		queries[i] = Query{
+			DatasetName:       "Dataset" + strconv.Itoa(i),
+			DimensionsOptions: []DimensionOptions{{Name: "SEX"}},
+			RootDimension:     "SEX",
+			Limit:             10,
+		}
+	}
+
+	c := NewClientWithOptions(srv.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 1}), WithLogger(discardLogger{}))
+	c.QueryMany(context.Background(), queries, QueryManyOptions{Concurrency: 2})
+
+	if maxInFlight == 0 || maxInFlight > 2 {
+		t.Errorf("codebook requests in flight = %d, want between 1 and 2", maxInFlight)
+	}
+}
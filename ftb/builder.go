@@ -0,0 +1,170 @@
+package ftb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// QueryBuilder builds a Query, validating dimension names and option codes against the dataset codebook before any
+// query is sent to FTB.
+type QueryBuilder struct {
+	cli        Clienter
+	dataset    string
+	root       string
+	limit      int
+	offset     int
+	dimensions []DimensionOptions
+	problems   []string
+}
+
+func NewQueryBuilder(cli Clienter) *QueryBuilder {
+	return &QueryBuilder{
+		cli:        cli,
+		dimensions: make([]DimensionOptions, 0),
+		problems:   make([]string, 0),
+	}
+}
+
+func (b *QueryBuilder) Dataset(name string) *QueryBuilder {
+	b.dataset = name
+	return b
+}
+
+// Select adds a dimension restricted to the codes provided.
+func (b *QueryBuilder) Select(dimension string, codes ...string) *QueryBuilder {
+	if len(codes) == 0 {
+		b.problems = append(b.problems, fmt.Sprintf("dimension %s selected with no options, use All to select every option", dimension))
+	}
+
+	b.dimensions = append(b.dimensions, DimensionOptions{Name: dimension, Options: codes})
+	return b
+}
+
+// All adds a dimension with every option selected.
+func (b *QueryBuilder) All(dimension string) *QueryBuilder {
+	b.dimensions = append(b.dimensions, DimensionOptions{Name: dimension, Options: []string{}})
+	return b
+}
+
+// Exclude adds a dimension with every option selected except the codes provided.
+func (b *QueryBuilder) Exclude(dimension string, codes ...string) *QueryBuilder {
+	b.dimensions = append(b.dimensions, DimensionOptions{Name: dimension, Options: []string{}, Exclude: codes})
+	return b
+}
+
+// Root sets the dimension disclosure control rules are evaluated on. If not set the dataset's rule root variable is
+// used.
+func (b *QueryBuilder) Root(dimension string) *QueryBuilder {
+	b.root = dimension
+	return b
+}
+
+func (b *QueryBuilder) Limit(limit int) *QueryBuilder {
+	b.limit = limit
+	return b
+}
+
+func (b *QueryBuilder) Offset(offset int) *QueryBuilder {
+	b.offset = offset
+	return b
+}
+
+// Build validates the query against the dataset codebook and returns it, or a *ValidationError listing every problem
+// found.
+func (b *QueryBuilder) Build(ctx context.Context) (*Query, error) {
+	problems := append([]string{}, b.problems...)
+
+	if b.dataset == "" {
+		problems = append(problems, "dataset is required")
+	}
+
+	if len(b.dimensions) == 0 {
+		problems = append(problems, "at least one dimension is required")
+	}
+
+	if b.limit < 0 {
+		problems = append(problems, fmt.Sprintf("limit %d must not be negative", b.limit))
+	}
+
+	if b.offset < 0 {
+		problems = append(problems, fmt.Sprintf("offset %d must not be negative", b.offset))
+	}
+
+	names := make([]string, 0)
+	seen := make(map[string]bool, 0)
+	for _, d := range b.dimensions {
+		key := strings.ToUpper(d.Name)
+		if seen[key] {
+			problems = append(problems, fmt.Sprintf("dimension %s selected more than once", d.Name))
+			continue
+		}
+		seen[key] = true
+		names = append(names, d.Name)
+	}
+
+	if b.root != "" && !seen[strings.ToUpper(b.root)] {
+		names = append(names, b.root)
+	}
+
+	if b.dataset == "" || len(names) == 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	cb, err := b.cli.GetCodebook(ctx, b.dataset, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range b.dimensions {
+		details := cb.GetDimension(d.Name)
+		if details == nil {
+			problems = append(problems, fmt.Sprintf("dimension %s not in dataset %s", d.Name, b.dataset))
+			continue
+		}
+
+		codes := make(map[string]bool, 0)
+		for _, code := range details.Codes {
+			codes[code] = true
+		}
+
+		selected := make(map[string]bool, 0)
+		for _, opt := range d.Options {
+			switch {
+			case opt == "":
+				problems = append(problems, fmt.Sprintf("dimension %s has an empty option", d.Name))
+			case selected[opt]:
+				problems = append(problems, fmt.Sprintf("dimension %s option %s selected more than once", d.Name, opt))
+			case !codes[opt]:
+				problems = append(problems, fmt.Sprintf("dimension %s has no option %s", d.Name, opt))
+			}
+			selected[opt] = true
+		}
+
+		for _, code := range d.Exclude {
+			if !codes[code] {
+				problems = append(problems, fmt.Sprintf("dimension %s has no option %s to exclude", d.Name, code))
+			}
+		}
+
+		if len(d.Exclude) > 0 && len(resolveOptions(d, details)) == 0 {
+			problems = append(problems, fmt.Sprintf("dimension %s excludes every option", d.Name))
+		}
+	}
+
+	if b.root != "" && cb.GetDimension(b.root) == nil {
+		problems = append(problems, fmt.Sprintf("root dimension %s not in dataset %s", b.root, b.dataset))
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return &Query{
+		DatasetName:       b.dataset,
+		DimensionsOptions: b.dimensions,
+		RootDimension:     b.root,
+		Limit:             b.limit,
+		Offset:            b.offset,
+	}, nil
+}
@@ -0,0 +1,171 @@
+package ftb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+// stubCodebookClient serves GetCodebook from a fixed set of dimensions. Unknown variables are left out of the codebook
+// returned. Any other Clienter method panics.
+type stubCodebookClient struct {
+	Clienter
+	dimensions []codebook.Dimension
+	err        error
+	calls      int
+}
+
+func (s *stubCodebookClient) GetCodebook(ctx context.Context, dataset string, vars ...string) (*codebook.Codebook, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	cb := &codebook.Codebook{Dataset: codebook.Dataset{Name: dataset}, CodeBook: make([]codebook.Dimension, 0)}
+	for _, v := range vars {
+		for _, d := range s.dimensions {
+			if d.Name == v {
+				cb.CodeBook = append(cb.CodeBook, d)
+			}
+		}
+	}
+
+	return cb, nil
+}
+
+func TestQueryBuilderBuild(t *testing.T) {
+	dimensions := []codebook.Dimension{
+		{Name: "COUNTRY", Codes: []string{"E", "W"}},
+		{Name: "SEX", Codes: []string{"1", "2"}},
+		{Name: "AGE", Codes: []string{"0", "1", "2"}},
+	}
+	codebookErr := errors.New("codebook unavailable")
+
+	tests := []struct {
+		name         string
+		build        func(b *QueryBuilder) *QueryBuilder
+		codebookErr  error
+		want         *Query
+		wantProblems []string
+		wantErr      error
+		wantCalls    int
+	}{
+		{
+			name: "valid query",
+			build: func(b *QueryBuilder) *QueryBuilder {
+				return b.Dataset("People").Select("SEX", "1").Exclude("AGE", "0").All("COUNTRY").Root("COUNTRY").Limit(5).Offset(2)
+			},
+			want: &Query{
+				DatasetName: "People",
+				DimensionsOptions: []DimensionOptions{
+					{Name: "SEX", Options: []string{"1"}},
+					{Name: "AGE", Options: []string{}, Exclude: []string{"0"}},
+					{Name: "COUNTRY", Options: []string{}},
+				},
+				RootDimension: "COUNTRY",
+				Limit:         5,
+				Offset:        2,
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "every problem without a codebook request",
+			build: func(b *QueryBuilder) *QueryBuilder {
+				return b.Limit(-1).Offset(-2)
+			},
+			wantProblems: []string{
+				"dataset is required",
+				"at least one dimension is required",
+				"limit -1 must not be negative",
+				"offset -2 must not be negative",
+			},
+		},
+		{
+			name: "every problem with the codebook",
+			build: func(b *QueryBuilder) *QueryBuilder {
+				return b.Dataset("People").Select("SEX").Select("AGE", "0", "", "0", "9").All("RELIGION").Root("REGION")
+			},
+			wantProblems: []string{
+				"dimension SEX selected with no options, use All to select every option",
+				"dimension AGE has an empty option",
+				"dimension AGE option 0 selected more than once",
+				"dimension AGE has no option 9",
+				"dimension RELIGION not in dataset People",
+				"root dimension REGION not in dataset People",
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "duplicate dimensions",
+			build: func(b *QueryBuilder) *QueryBuilder {
+				return b.Dataset("People").All("SEX").Select("sex", "1")
+			},
+			wantProblems: []string{"dimension sex selected more than once"},
+			wantCalls:    1,
+		},
+		{
+			name: "unknown excluded codes",
+			build: func(b *QueryBuilder) *QueryBuilder {
+				return b.Dataset("People").Exclude("AGE", "0", "9")
+			},
+			wantProblems: []string{"dimension AGE has no option 9 to exclude"},
+			wantCalls:    1,
+		},
+		{
+			name: "exclude every option",
+			build: func(b *QueryBuilder) *QueryBuilder {
+				return b.Dataset("People").Exclude("SEX", "1", "2")
+			},
+			wantProblems: []string{"dimension SEX excludes every option"},
+			wantCalls:    1,
+		},
+		{
+			name: "codebook error",
+			build: func(b *QueryBuilder) *QueryBuilder {
+				return b.Dataset("People").All("SEX")
+			},
+			codebookErr: codebookErr,
+			wantErr:     codebookErr,
+			wantCalls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &stubCodebookClient{dimensions: dimensions, err: tt.codebookErr}
+
+			got, err := tt.build(NewQueryBuilder(cli)).Build(context.Background())
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Build() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantProblems != nil:
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("Build() error = %v, want a *ValidationError", err)
+				}
+				if !reflect.DeepEqual(validationErr.Problems, tt.wantProblems) {
+					t.Errorf("Problems = %q, want %q", validationErr.Problems, tt.wantProblems)
+				}
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Errorf("Build() error = %v, want ErrInvalidQuery", err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Build() = %+v, want %+v", got, tt.want)
+			}
+
+			if cli.calls != tt.wantCalls {
+				t.Errorf("codebook requests = %d, want %d", cli.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package ftb
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+const (
+	DefaultCodebookCacheTTL        = time.Hour
+	DefaultCodebookCacheMaxEntries = 1000
+)
+
+type codebookCacheKey struct {
+	dataset   string
+	digest    string
+	dimension string
+}
+
+type codebookCacheEntry struct {
+	dimension *codebook.Dimension
+	expires   time.Time
+}
+
+type fullCodebookEntry struct {
+	codebook *codebook.Codebook
+	expires  time.Time
+}
+
+type rootVariableEntry struct {
+	name    string
+	expires time.Time
+}
+
+// codebookCache holds codebook dimensions keyed on dataset, dataset digest and dimension name. Codebooks only change when
+// a dataset is reloaded, which changes its digest, so entries for a dataset are dropped when a new digest is observed.
+// The full codebook and rule root variable of each dataset are held alongside and dropped with them.
+type codebookCache struct {
+	mu            sync.Mutex
+	ttl           time.Duration
+	maxEntries    int
+	digests       map[string]string
+	entries       map[codebookCacheKey]codebookCacheEntry
+	codebooks     map[string]fullCodebookEntry
+	rootVariables map[string]rootVariableEntry
+}
+
+// newCodebookCache returns a cache bounded by the ttl and max entries provided, or nil if maxEntries is not positive.
+func newCodebookCache(ttl time.Duration, maxEntries int) *codebookCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+
+	return &codebookCache{
+		ttl:           ttl,
+		maxEntries:    maxEntries,
+		digests:       make(map[string]string),
+		entries:       make(map[codebookCacheKey]codebookCacheEntry),
+		codebooks:     make(map[string]fullCodebookEntry),
+		rootVariables: make(map[string]rootVariableEntry),
+	}
+}
+
+func newCodebookCacheKey(dataset, digest, dimension string) codebookCacheKey {
+	return codebookCacheKey{dataset: dataset, digest: digest, dimension: strings.ToUpper(dimension)}
+}
+
+// observeDigest records the current digest of a dataset, dropping any entries cached for a previous digest.
+func (cc *codebookCache) observeDigest(dataset, digest string) {
+	if cc == nil || digest == "" {
+		return
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	current, ok := cc.digests[dataset]
+	if ok && current == digest {
+		return
+	}
+
+	if ok {
+		delete(cc.rootVariables, dataset)
+	}
+
+	if entry, ok := cc.codebooks[dataset]; ok && entry.codebook.Dataset.Digest != digest {
+		delete(cc.codebooks, dataset)
+	}
+
+	for k := range cc.entries {
+		if k.dataset == dataset && k.digest != digest {
+			delete(cc.entries, k)
+		}
+	}
+	cc.digests[dataset] = digest
+}
+
+func (cc *codebookCache) get(dataset, digest, dimension string) *codebook.Dimension {
+	if cc == nil {
+		return nil
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	// Callers that have not yet queried FTB do not know the digest, so use the latest one observed.
+	if digest == "" {
+		digest = cc.digests[dataset]
+	}
+
+	key := newCodebookCacheKey(dataset, digest, dimension)
+	entry, ok := cc.entries[key]
+	if !ok {
+		return nil
+	}
+
+	if cc.ttl > 0 && time.Now().After(entry.expires) {
+		delete(cc.entries, key)
+		return nil
+	}
+
+	return entry.dimension
+}
+
+func (cc *codebookCache) put(dataset, digest string, dimension *codebook.Dimension) {
+	if cc == nil {
+		return
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	key := newCodebookCacheKey(dataset, digest, dimension.Name)
+	if _, ok := cc.entries[key]; !ok && len(cc.entries) >= cc.maxEntries {
+		cc.evictOldest()
+	}
+
+	cc.entries[key] = codebookCacheEntry{
+		dimension: dimension,
+		expires:   time.Now().Add(cc.ttl),
+	}
+}
+
+// evictOldest removes the entry closest to expiry. Must be called with the lock held.
+func (cc *codebookCache) evictOldest() {
+	var oldestKey codebookCacheKey
+	var oldest time.Time
+	found := false
+
+	for k, e := range cc.entries {
+		if !found || e.expires.Before(oldest) {
+			oldestKey, oldest, found = k, e.expires, true
+		}
+	}
+
+	if found {
+		delete(cc.entries, oldestKey)
+	}
+}
+
+// getRootVariable returns the rule root variable cached for the dataset, if any.
+func (cc *codebookCache) getRootVariable(dataset string) (string, bool) {
+	if cc == nil {
+		return "", false
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	entry, ok := cc.rootVariables[dataset]
+	if !ok {
+		return "", false
+	}
+
+	if cc.ttl > 0 && time.Now().After(entry.expires) {
+		delete(cc.rootVariables, dataset)
+		return "", false
+	}
+
+	return entry.name, true
+}
+
+func (cc *codebookCache) putRootVariable(dataset, name string) {
+	if cc == nil {
+		return
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.rootVariables[dataset] = rootVariableEntry{
+		name:    name,
+		expires: time.Now().Add(cc.ttl),
+	}
+}
+
+// getCodebook returns the full codebook cached for the dataset, or nil if there is none or it is for a previous digest.
+func (cc *codebookCache) getCodebook(dataset string) *codebook.Codebook {
+	if cc == nil {
+		return nil
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	entry, ok := cc.codebooks[dataset]
+	if !ok {
+		return nil
+	}
+
+	if cc.ttl > 0 && time.Now().After(entry.expires) {
+		delete(cc.codebooks, dataset)
+		return nil
+	}
+
+	if digest, ok := cc.digests[dataset]; ok && digest != entry.codebook.Dataset.Digest {
+		delete(cc.codebooks, dataset)
+		return nil
+	}
+
+	return entry.codebook
+}
+
+func (cc *codebookCache) putCodebook(dataset string, cb *codebook.Codebook) {
+	if cc == nil {
+		return
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.codebooks[dataset] = fullCodebookEntry{
+		codebook: cb,
+		expires:  time.Now().Add(cc.ttl),
+	}
+}
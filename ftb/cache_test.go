@@ -0,0 +1,211 @@
+package ftb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+func TestCodebookCacheDigests(t *testing.T) {
+	sex := &codebook.Dimension{Name: "SEX"}
+	age := &codebook.Dimension{Name: "AGE"}
+
+	tests := []struct {
+		name    string
+		setup   func(cc *codebookCache)
+		dataset string
+		digest  string
+		lookup  string
+		wantHit bool
+	}{
+		{
+			name:    "entry for the digest",
+			setup:   func(cc *codebookCache) { cc.put("People", "d1", sex) },
+			digest:  "d1",
+			lookup:  "SEX",
+			wantHit: true,
+		},
+		{
+			name:    "dimension name matched case insensitively",
+			setup:   func(cc *codebookCache) { cc.put("People", "d1", sex) },
+			digest:  "d1",
+			lookup:  "sex",
+			wantHit: true,
+		},
+		{
+			name:   "entry for another digest",
+			setup:  func(cc *codebookCache) { cc.put("People", "d1", sex) },
+			digest: "d2",
+			lookup: "SEX",
+		},
+		{
+			name: "empty digest uses the latest observed digest",
+			setup: func(cc *codebookCache) {
+				cc.observeDigest("People", "d1")
+				cc.put("People", "d1", sex)
+			},
+			lookup:  "SEX",
+			wantHit: true,
+		},
+		{
+			name:   "empty digest without an observed digest",
+			setup:  func(cc *codebookCache) { cc.put("People", "d1", sex) },
+			lookup: "SEX",
+		},
+		{
+			name: "new digest drops entries for the previous digest",
+			setup: func(cc *codebookCache) {
+				cc.observeDigest("People", "d1")
+				cc.put("People", "d1", sex)
+				cc.observeDigest("People", "d2")
+			},
+			digest: "d1",
+			lookup: "SEX",
+		},
+		{
+			name: "new digest keeps entries for other datasets",
+			setup: func(cc *codebookCache) {
+				cc.put("Households", "h1", age)
+				cc.observeDigest("People", "d1")
+				cc.observeDigest("People", "d2")
+			},
+			dataset: "Households",
+			digest:  "h1",
+			lookup:  "AGE",
+			wantHit: true,
+		},
+		{
+			name: "observing the same digest keeps entries",
+			setup: func(cc *codebookCache) {
+				cc.observeDigest("People", "d1")
+				cc.put("People", "d1", sex)
+				cc.observeDigest("People", "d1")
+			},
+			digest:  "d1",
+			lookup:  "SEX",
+			wantHit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := newCodebookCache(time.Hour, 10)
+			tt.setup(cc)
+
+			dataset := tt.dataset
+			if dataset == "" {
+				dataset = "People"
+			}
+
+			got := cc.get(dataset, tt.digest, tt.lookup)
+			if hit := got != nil; hit != tt.wantHit {
+				t.Errorf("get() hit = %v, want %v", hit, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestCodebookCacheExpiry(t *testing.T) {
+	cc := newCodebookCache(time.Millisecond, 10)
+	cc.put("People", "d1", &codebook.Dimension{Name: "SEX"})
+	cc.putRootVariable("People", "COUNTRY")
+
+	time.Sleep(5 * time.Millisecond)
+
+	if got := cc.get("People", "d1", "SEX"); got != nil {
+		t.Error("get() returned an expired entry")
+	}
+
+	if _, ok := cc.getRootVariable("People"); ok {
+		t.Error("getRootVariable() returned an expired entry")
+	}
+
+	if len(cc.entries) != 0 || len(cc.rootVariables) != 0 {
+		t.Errorf("expired entries were not removed: %d codebook, %d root", len(cc.entries), len(cc.rootVariables))
+	}
+}
+
+func TestCodebookCacheEvictsOldest(t *testing.T) {
+	cc := newCodebookCache(time.Hour, 2)
+	cc.put("People", "d1", &codebook.Dimension{Name: "SEX"})
+	time.Sleep(time.Millisecond)
+	cc.put("People", "d1", &codebook.Dimension{Name: "AGE"})
+	time.Sleep(time.Millisecond)
+
+	// Replacing an entry does not evict another.
+	cc.put("People", "d1", &codebook.Dimension{Name: "AGE"})
+	if cc.get("People", "d1", "SEX") == nil {
+		t.Fatal("replacing an entry evicted another")
+	}
+
+	cc.put("People", "d1", &codebook.Dimension{Name: "COUNTRY"})
+
+	if len(cc.entries) != 2 {
+		t.Errorf("entries = %d, want 2", len(cc.entries))
+	}
+
+	if cc.get("People", "d1", "SEX") != nil {
+		t.Error("oldest entry SEX was not evicted")
+	}
+
+	for _, name := range []string{"AGE", "COUNTRY"} {
+		if cc.get("People", "d1", name) == nil {
+			t.Errorf("entry %s was evicted, want SEX evicted", name)
+		}
+	}
+}
+
+func TestCodebookCacheRootVariables(t *testing.T) {
+	cc := newCodebookCache(time.Hour, 10)
+
+	cc.putRootVariable("People", "COUNTRY")
+	cc.observeDigest("People", "d1")
+	if name, ok := cc.getRootVariable("People"); !ok || name != "COUNTRY" {
+		t.Errorf("getRootVariable() = %q, %v after first digest, want COUNTRY, true", name, ok)
+	}
+
+	cc.observeDigest("People", "d2")
+	if _, ok := cc.getRootVariable("People"); ok {
+		t.Error("getRootVariable() returned a root variable cached for a previous digest")
+	}
+}
+
+func TestNilCodebookCache(t *testing.T) {
+	cc := newCodebookCache(time.Hour, 0)
+	if cc != nil {
+		t.Fatal("newCodebookCache() with no entries should disable the cache")
+	}
+
+	cc.observeDigest("People", "d1")
+	cc.put("People", "d1", &codebook.Dimension{Name: "SEX"})
+	cc.putRootVariable("People", "COUNTRY")
+
+	if cc.get("People", "d1", "SEX") != nil {
+		t.Error("get() on a nil cache returned an entry")
+	}
+
+	if _, ok := cc.getRootVariable("People"); ok {
+		t.Error("getRootVariable() on a nil cache returned an entry")
+	}
+}
+
+func TestCodebookCacheFullCodebooks(t *testing.T) {
+	cc := newCodebookCache(time.Hour, 10)
+	cb := &codebook.Codebook{Dataset: codebook.Dataset{Name: "People", Digest: "d1"}}
+
+	cc.putCodebook("People", cb)
+	if got := cc.getCodebook("People"); got != cb {
+		t.Fatalf("getCodebook() = %v, want the cached codebook", got)
+	}
+
+	cc.observeDigest("People", "d1")
+	if got := cc.getCodebook("People"); got != cb {
+		t.Errorf("getCodebook() = %v after observing its digest, want the cached codebook", got)
+	}
+
+	cc.observeDigest("People", "d2")
+	if got := cc.getCodebook("People"); got != nil {
+		t.Errorf("getCodebook() = %v, want nil after the digest changed", got)
+	}
+}
@@ -0,0 +1,97 @@
+package ftb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+// CategoryRange is a contiguous run of category indices within the codebook of a dimension.
+type CategoryRange struct {
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+}
+
+// DimensionCategories describes which category indices FTB returned for a dimension of a query.
+type DimensionCategories struct {
+	Name   string          `json:"name"`
+	Ranges []CategoryRange `json:"ranges"`
+}
+
+// Indices returns the category indices of the dimension in the order FTB returned them.
+func (d DimensionCategories) Indices() []int {
+	indices := make([]int, 0, d.Count())
+	for _, r := range d.Ranges {
+		for i := r.Offset; i < r.Offset+r.Length; i++ {
+			indices = append(indices, i)
+		}
+	}
+
+	return indices
+}
+
+// Count returns the number of categories returned for the dimension.
+func (d DimensionCategories) Count() int {
+	n := 0
+	for _, r := range d.Ranges {
+		n += r.Length
+	}
+
+	return n
+}
+
+func parseOffsetLenPairs(pairs []int) ([]CategoryRange, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("incorrect input")
+	}
+
+	ranges := make([]CategoryRange, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		ranges = append(ranges, CategoryRange{Offset: pairs[i], Length: pairs[i+1]})
+	}
+
+	return ranges, nil
+}
+
+// checkCategories verifies the categories FTB returned for each dimension match the options requested, in order, so
+// rows are not labelled with the wrong codes. Dimensions FTB did not report on are not checked.
+func checkCategories(queryOptions []DimensionOptions, dimensions map[string]*codebook.Dimension, categories []DimensionCategories) error {
+	for _, d := range queryOptions {
+		details := dimensions[d.Name]
+		if details == nil {
+			continue
+		}
+
+		returned, ok := findCategories(categories, d.Name)
+		if !ok {
+			continue
+		}
+
+		requested := resolveOptions(d, details)
+		indices := returned.Indices()
+		if len(indices) != len(requested) {
+			return fmt.Errorf("%w: dimension %s requested %d options but FTB returned %d", ErrResultShapeMismatch, d.Name, len(requested), len(indices))
+		}
+
+		for i, code := range requested {
+			index := indices[i]
+			if index < 0 || index >= len(details.Codes) || details.Codes[index] != code {
+				return fmt.Errorf("%w: dimension %s option %d requested %s but FTB returned category %d", ErrResultShapeMismatch, d.Name, i, code, index)
+			}
+		}
+	}
+
+	return nil
+}
+
+func findCategories(categories []DimensionCategories, name string) (DimensionCategories, bool) {
+	for _, c := range categories {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+
+	return DimensionCategories{}, false
+}
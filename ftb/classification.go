@@ -0,0 +1,268 @@
+package ftb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+// ClassificationGraph describes how the dimensions of a dataset are derived from one another. A derived dimension
+// (e.g. AGE_2CATS) maps from a finer source dimension (e.g. AGE): MapFrom names the source dimension and MapFromCodes
+// holds, for each code of the source dimension in codebook order, the derived code it is mapped to.
+type ClassificationGraph struct {
+	dimensions map[string]*codebook.Dimension
+	// coarser maps a dimension to the dimensions derived directly from it.
+	coarser map[string][]string
+	// finer maps a derived dimension to the dimension it is derived from.
+	finer map[string]string
+	// mappings holds the derived code for each source code, keyed on the derived dimension.
+	mappings map[string]map[string]string
+}
+
+// GetClassificationGraph returns the classification graph built from the full codebook of the dataset. The codebook is
+// cached with the dataset codebooks so it is only requested from FTB once per dataset digest.
+func (c *client) GetClassificationGraph(ctx context.Context, dataset string) (*ClassificationGraph, error) {
+	cb, err := c.getFullCodebook(ctx, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClassificationGraph(cb)
+}
+
+func NewClassificationGraph(cb *codebook.Codebook) (*ClassificationGraph, error) {
+	g := &ClassificationGraph{
+		dimensions: make(map[string]*codebook.Dimension, 0),
+		coarser:    make(map[string][]string, 0),
+		finer:      make(map[string]string, 0),
+		mappings:   make(map[string]map[string]string, 0),
+	}
+
+	for i := range cb.CodeBook {
+		d := &cb.CodeBook[i]
+		g.dimensions[strings.ToUpper(d.Name)] = d
+	}
+
+	for _, d := range g.dimensions {
+		if len(d.MapFrom) == 0 {
+			continue
+		}
+
+		source, ok := g.dimensions[strings.ToUpper(d.MapFrom[0])]
+		if !ok {
+			// The source dimension is not in the codebook so the mapping cannot be resolved.
+			continue
+		}
+
+		if len(d.MapFromCodes) != len(source.Codes) {
+			return nil, fmt.Errorf("dimension %s maps %d codes from %s which has %d codes", d.Name, len(d.MapFromCodes), source.Name, len(source.Codes))
+		}
+
+		mapping := make(map[string]string, len(source.Codes))
+		for i, code := range source.Codes {
+			mapping[code] = d.MapFromCodes[i]
+		}
+
+		derived := strings.ToUpper(d.Name)
+		sourceName := strings.ToUpper(source.Name)
+		g.coarser[sourceName] = append(g.coarser[sourceName], d.Name)
+		g.finer[derived] = source.Name
+		g.mappings[derived] = mapping
+	}
+
+	if err := g.checkAcyclic(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// checkAcyclic returns an error if following MapFrom from any dimension leads back to that dimension.
+func (g *ClassificationGraph) checkAcyclic() error {
+	for derived := range g.finer {
+		visited := map[string]bool{derived: true}
+		current := derived
+
+		for {
+			parent, ok := g.finer[current]
+			if !ok {
+				break
+			}
+
+			current = strings.ToUpper(parent)
+			if visited[current] {
+				return fmt.Errorf("dimension %s is derived from itself through mapFrom", g.dimensions[derived].Name)
+			}
+			visited[current] = true
+		}
+	}
+
+	return nil
+}
+
+// Coarser returns the dimensions derived directly from the dimension.
+func (g *ClassificationGraph) Coarser(dimension string) []string {
+	return g.coarser[strings.ToUpper(dimension)]
+}
+
+// Finer returns the dimension the dimension is derived from, or an empty string if it is not derived.
+func (g *ClassificationGraph) Finer(dimension string) string {
+	return g.finer[strings.ToUpper(dimension)]
+}
+
+// IsCoarser reports whether to is derived, directly or indirectly, from the dimension from.
+func (g *ClassificationGraph) IsCoarser(from, to string) bool {
+	_, ok := g.pathToCoarser(from, to)
+	return ok
+}
+
+// pathToCoarser returns the chain of dimensions from a finer dimension up to a coarser one, excluding from.
+func (g *ClassificationGraph) pathToCoarser(from, to string) ([]string, bool) {
+	path := make([]string, 0)
+	current := strings.ToUpper(to)
+	target := strings.ToUpper(from)
+	visited := make(map[string]bool, 0)
+
+	for current != target {
+		if visited[current] {
+			return nil, false
+		}
+		visited[current] = true
+		path = append([]string{current}, path...)
+
+		parent, ok := g.finer[current]
+		if !ok {
+			return nil, false
+		}
+		current = strings.ToUpper(parent)
+	}
+
+	return path, true
+}
+
+// TranslateCodes translates codes of one dimension into the codes of another dimension in the same classification
+// hierarchy. Translating to a coarser dimension returns the codes the inputs are grouped into, and translating to a finer
+// dimension returns every code grouped under the inputs. Codes are returned in codebook order.
+func (g *ClassificationGraph) TranslateCodes(from, to string, codes ...string) ([]string, error) {
+	if strings.EqualFold(from, to) {
+		return codes, nil
+	}
+
+	if path, ok := g.pathToCoarser(from, to); ok {
+		current := codes
+		for _, dim := range path {
+			current = g.coarsen(dim, current)
+		}
+		return current, nil
+	}
+
+	if path, ok := g.pathToCoarser(to, from); ok {
+		current := codes
+		for i := len(path) - 1; i >= 0; i-- {
+			current = g.refine(path[i], current)
+		}
+		return current, nil
+	}
+
+	return nil, fmt.Errorf("%w: dimensions %s and %s are not in the same classification hierarchy", ErrNotFound, from, to)
+}
+
+// coarsen maps source codes to the derived dimension.
+func (g *ClassificationGraph) coarsen(derived string, codes []string) []string {
+	mapping := g.mappings[derived]
+	selected := make(map[string]bool, 0)
+	for _, code := range codes {
+		if target, ok := mapping[code]; ok {
+			selected[target] = true
+		}
+	}
+
+	return g.inCodebookOrder(derived, selected)
+}
+
+// refine maps derived codes back to every source code grouped under them.
+func (g *ClassificationGraph) refine(derived string, codes []string) []string {
+	wanted := make(map[string]bool, 0)
+	for _, code := range codes {
+		wanted[code] = true
+	}
+
+	selected := make(map[string]bool, 0)
+	for source, target := range g.mappings[derived] {
+		if wanted[target] {
+			selected[source] = true
+		}
+	}
+
+	return g.inCodebookOrder(g.finer[derived], selected)
+}
+
+func (g *ClassificationGraph) inCodebookOrder(dimension string, selected map[string]bool) []string {
+	result := make([]string, 0)
+	d, ok := g.dimensions[strings.ToUpper(dimension)]
+	if !ok {
+		return result
+	}
+
+	for _, code := range d.Codes {
+		if selected[code] {
+			result = append(result, code)
+		}
+	}
+
+	return result
+}
+
+// Coarsen returns a copy of the query with the dimension replaced by a coarser classification, translating any selected
+// options into the codes of the coarser dimension. Excluded options are applied to the selection before it is
+// translated, so a coarser code is kept if any of the codes grouped under it remain selected.
+func (g *ClassificationGraph) Coarsen(q Query, dimension, to string) (Query, error) {
+	if !g.IsCoarser(dimension, to) {
+		return q, fmt.Errorf("%w: dimension %s is not a coarser classification of %s", ErrInvalidQuery, to, dimension)
+	}
+
+	coarsened := q
+	coarsened.DimensionsOptions = make([]DimensionOptions, 0, len(q.DimensionsOptions))
+	found := false
+
+	for _, d := range q.DimensionsOptions {
+		if !strings.EqualFold(d.Name, dimension) {
+			coarsened.DimensionsOptions = append(coarsened.DimensionsOptions, d)
+			continue
+		}
+
+		found = true
+		options := []string{}
+		if len(d.Options) > 0 || len(d.Exclude) > 0 {
+			selected := resolveOptions(d, g.dimensions[strings.ToUpper(dimension)])
+			translated, err := g.TranslateCodes(dimension, to, selected...)
+			if err != nil {
+				return q, err
+			}
+
+			if len(translated) == 0 {
+				return q, fmt.Errorf("%w: dimension %s selects no options", ErrInvalidQuery, dimension)
+			}
+
+			// Exclusions that leave every coarser code selected are sent as a wildcard, like the unfiltered dimension.
+			coarser, ok := g.dimensions[strings.ToUpper(to)]
+			if len(d.Options) > 0 || !ok || len(translated) < len(coarser.Codes) {
+				options = translated
+			}
+		}
+
+		coarsened.DimensionsOptions = append(coarsened.DimensionsOptions, DimensionOptions{Name: to, Options: options})
+	}
+
+	if !found {
+		return q, fmt.Errorf("%w: dimension %s is not in the query", ErrInvalidQuery, dimension)
+	}
+
+	if strings.EqualFold(q.RootDimension, dimension) {
+		coarsened.RootDimension = to
+	}
+
+	return coarsened, nil
+}
@@ -0,0 +1,165 @@
+package ftb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+// testClassification returns a codebook with AGE grouped into AGE_3CATS, which is grouped into AGE_2CATS.
+func testClassification() *codebook.Codebook {
+	return &codebook.Codebook{
+		CodeBook: []codebook.Dimension{
+			{Name: "AGE", Codes: []string{"0", "1", "2", "3", "4"}, Labels: []string{"0", "1", "2", "3", "4"}},
+			{
+				Name:         "AGE_3CATS",
+				Codes:        []string{"A", "B", "C"},
+				Labels:       []string{"0-1", "2-3", "4"},
+				MapFrom:      []string{"AGE"},
+				MapFromCodes: []string{"A", "A", "B", "B", "C"},
+			},
+			{
+				Name:         "AGE_2CATS",
+				Codes:        []string{"X", "Y"},
+				Labels:       []string{"0-3", "4"},
+				MapFrom:      []string{"AGE_3CATS"},
+				MapFromCodes: []string{"X", "X", "Y"},
+			},
+			{Name: "SEX", Codes: []string{"1", "2"}, Labels: []string{"Male", "Female"}},
+		},
+	}
+}
+
+func TestTranslateCodes(t *testing.T) {
+	g, err := NewClassificationGraph(testClassification())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		codes   []string
+		want    []string
+		wantErr error
+	}{
+		{name: "same dimension", from: "AGE", to: "age", codes: []string{"3", "1"}, want: []string{"3", "1"}},
+		{name: "one level coarser", from: "AGE", to: "AGE_3CATS", codes: []string{"3", "0", "1"}, want: []string{"A", "B"}},
+		{name: "two levels coarser", from: "AGE", to: "AGE_2CATS", codes: []string{"4"}, want: []string{"Y"}},
+		{name: "one level finer", from: "AGE_3CATS", to: "AGE", codes: []string{"B"}, want: []string{"2", "3"}},
+		{name: "two levels finer", from: "AGE_2CATS", to: "AGE", codes: []string{"X"}, want: []string{"0", "1", "2", "3"}},
+		{name: "unknown codes are dropped", from: "AGE", to: "AGE_3CATS", codes: []string{"9", "4"}, want: []string{"C"}},
+		{name: "unrelated dimensions", from: "AGE", to: "SEX", codes: []string{"1"}, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.TranslateCodes(tt.from, tt.to, tt.codes...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("TranslateCodes() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TranslateCodes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClassificationGraphRejectsCycles(t *testing.T) {
+	cb := &codebook.Codebook{
+		CodeBook: []codebook.Dimension{
+			{Name: "A", Codes: []string{"1"}, MapFrom: []string{"B"}, MapFromCodes: []string{"1"}},
+			{Name: "B", Codes: []string{"1"}, MapFrom: []string{"A"}, MapFromCodes: []string{"1"}},
+		},
+	}
+
+	if _, err := NewClassificationGraph(cb); err == nil {
+		t.Error("NewClassificationGraph() error = nil, want an error for the cycle")
+	}
+}
+
+func TestCoarsen(t *testing.T) {
+	g, err := NewClassificationGraph(testClassification())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		dimension DimensionOptions
+		to        string
+		want      DimensionOptions
+		wantErr   error
+	}{
+		{
+			name:      "wildcard stays a wildcard",
+			dimension: DimensionOptions{Name: "AGE"},
+			to:        "AGE_3CATS",
+			want:      DimensionOptions{Name: "AGE_3CATS", Options: []string{}},
+		},
+		{
+			name:      "options are translated",
+			dimension: DimensionOptions{Name: "AGE", Options: []string{"0", "2"}},
+			to:        "AGE_3CATS",
+			want:      DimensionOptions{Name: "AGE_3CATS", Options: []string{"A", "B"}},
+		},
+		{
+			name:      "exclusions removing a whole group are translated",
+			dimension: DimensionOptions{Name: "AGE", Exclude: []string{"2", "3"}},
+			to:        "AGE_3CATS",
+			want:      DimensionOptions{Name: "AGE_3CATS", Options: []string{"A", "C"}},
+		},
+		{
+			name:      "exclusions leaving every group selected become a wildcard",
+			dimension: DimensionOptions{Name: "AGE", Exclude: []string{"2"}},
+			to:        "AGE_3CATS",
+			want:      DimensionOptions{Name: "AGE_3CATS", Options: []string{}},
+		},
+		{
+			name:      "excluding every option is rejected",
+			dimension: DimensionOptions{Name: "AGE", Options: []string{"4"}, Exclude: []string{"4"}},
+			to:        "AGE_3CATS",
+			wantErr:   ErrInvalidQuery,
+		},
+		{
+			name:      "finer dimension is rejected",
+			dimension: DimensionOptions{Name: "AGE_3CATS"},
+			to:        "AGE",
+			wantErr:   ErrInvalidQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}, tt.dimension}}
+
+			got, err := g.Coarsen(q, tt.dimension.Name, tt.to)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Coarsen() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := []DimensionOptions{{Name: "SEX"}, tt.want}
+			if !reflect.DeepEqual(got.DimensionsOptions, want) {
+				t.Errorf("Coarsen() dimensions = %v, want %v", got.DimensionsOptions, want)
+			}
+		})
+	}
+}
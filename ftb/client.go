@@ -2,6 +2,10 @@ package ftb
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
 
 	"github.com/ONSdigital/dp-ftb-client-go/codebook"
 	dphttp "github.com/ONSdigital/dp-net/http"
@@ -9,20 +13,148 @@ import (
 
 type Clienter interface {
 	Query(ctx context.Context, q Query) (*QueryResult, error)
+	QueryMany(ctx context.Context, queries []Query, opts QueryManyOptions) []BatchResult
+	CheckDisclosure(ctx context.Context, q Query) (*DisclosureControlDetails, error)
+	SuggestAlternatives(ctx context.Context, q Query) ([]Alternative, error)
 	GetDimension(ctx context.Context, dataset, dimension string) (*codebook.Dimension, error)
+	GetCodebook(ctx context.Context, dataset string, vars ...string) (*codebook.Codebook, error)
 	GetDimensionByIndex(ctx context.Context, dataset, dimension string, index int) (*GetDimensionOptionResponse, error)
+	ListDatasets(ctx context.Context) (*codebook.Datasets, error)
+	GetDataset(ctx context.Context, name string) (*codebook.Dataset, error)
+	GetClassificationGraph(ctx context.Context, dataset string) (*ClassificationGraph, error)
+	EstimateCells(ctx context.Context, q Query) (int, error)
 }
 
 type client struct {
-	AuthToken string
-	Host      string
-	HttpCli   dphttp.Clienter
+	Tokens       TokenSource
+	Host         string
+	HttpCli      dphttp.Clienter
+	Retry        RetryPolicy
+	APIVersion   string
+	UserAgent    string
+	Timeout      time.Duration
+	Logger       Logger
+	DefaultLimit int
+	// MaxConcurrency bounds the number of requests a single client call makes to FTB at once.
+	MaxConcurrency int
+	// CodebookBatchSize is the maximum number of variables requested in one codebook request. Zero requests all
+	// variables at once.
+	CodebookBatchSize int
+	// MaxCells rejects queries estimated to produce more cells than the limit before they are sent to FTB. Status-only
+	// queries, which return no counts, are not checked. Zero disables the check.
+	MaxCells int
+	// Aliases resolves friendly or CMD dimension IDs to FTB variable names. Nil passes names to FTB unchanged.
+	Aliases *AliasRegistry
+	// CellAnnotator marks the observations of query results, e.g. to flag rounded or perturbed counts.
+	CellAnnotator CellAnnotator
+
+	codebookCache *codebookCache
 }
 
+// NewClient returns a client for the FTB host using the auth token and HTTP client provided. The client retries
+// transient failures itself, so httpCli should have its own retries disabled, e.g. with SetMaxRetries(0).
 func NewClient(host, authToken string, httpCli dphttp.Clienter) Clienter {
-	return &client{
-		AuthToken: authToken,
-		Host:      host,
-		HttpCli:   httpCli,
+	return NewClientWithOptions(host, WithAuthToken(authToken), WithHTTPClient(httpCli))
+}
+
+// NewClientWithOptions returns a client for the FTB host configured by the options provided. Unset options default to
+// a dp-net HTTP client with its retries disabled, the v6 API, the default retry policy and the log.go logger.
+func NewClientWithOptions(host string, opts ...Option) Clienter {
+	c := &client{
+		Host:       host,
+		Tokens:     StaticToken(""),
+		HttpCli:    newHTTPClient(),
+		Retry:      DefaultRetryPolicy,
+		APIVersion: DefaultAPIVersion,
+		Logger:     defaultLogger{},
+
+		MaxConcurrency: DefaultMaxConcurrency,
+
+		codebookCache: newCodebookCache(DefaultCodebookCacheTTL, DefaultCodebookCacheMaxEntries),
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// newHTTPClient returns a dp-net HTTP client that makes a single attempt at each request, leaving retries and
+// Retry-After handling to the client retry policy.
+func newHTTPClient() dphttp.Clienter {
+	cli := dphttp.NewClient()
+	cli.SetMaxRetries(0)
+	return cli
+}
+
+func (c *client) baseURL() string {
+	return c.Host + c.APIVersion
+}
+
+// get executes the request, retrying transient failures according to the client retry policy, and returns the
+// response body or an *Error if FTB returns an unsuccessful status code. If FTB rejects the auth token it is
+// invalidated and the request retried once with a refreshed token.
+func (c *client) get(ctx context.Context, operation string, r *http.Request) ([]byte, error) {
+	tokenRefreshed := false
+
+	for attempt := 1; ; attempt++ {
+		token, err := c.Tokens.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		body, retryAfter, err := c.do(ctx, operation, r, token)
+		if errors.Is(err, ErrUnauthorized) && !tokenRefreshed {
+			c.Tokens.Invalidate()
+			tokenRefreshed = true
+			attempt--
+			continue
+		}
+
+		if err == nil || attempt >= c.Retry.MaxAttempts || !isRetryable(ctx, err) {
+			return body, err
+		}
+
+		delay := c.Retry.backoff(attempt)
+		if retryAfter > 0 {
+			delay = c.Retry.retryAfter(retryAfter)
+		}
+
+		if !wait(ctx, delay) {
+			return nil, err
+		}
+	}
+}
+
+// do makes a single attempt at the request, returning any Retry-After delay requested by FTB.
+func (c *client) do(ctx context.Context, operation string, r *http.Request, token string) ([]byte, time.Duration, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	if c.UserAgent != "" {
+		r.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := c.HttpCli.Do(ctx, r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), newError(operation, r, resp.StatusCode, body)
+	}
+
+	return body, 0, nil
 }
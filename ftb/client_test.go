@@ -0,0 +1,173 @@
+package ftb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedServer responds to each request with the next status in the script, repeating the last status once the
+// script is exhausted, and records the Authorization header of each request.
+type scriptedServer struct {
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	auth       []string
+}
+
+func (s *scriptedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.statuses[len(s.statuses)-1]
+	if len(s.auth) < len(s.statuses) {
+		status = s.statuses[len(s.auth)]
+	}
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+
+	if status != http.StatusOK && s.retryAfter != "" {
+		w.Header().Set("Retry-After", s.retryAfter)
+	}
+	w.WriteHeader(status)
+	w.Write([]byte("{}"))
+}
+
+func (s *scriptedServer) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.auth)
+}
+
+func TestClientGetRetries(t *testing.T) {
+	noWait := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Nanosecond, MaxBackoff: time.Nanosecond}
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		policy       RetryPolicy
+		timeout      time.Duration
+		wantAttempts int
+		wantStatus   int
+		wantErr      error
+		wantMinWait  time.Duration
+		wantMaxWait  time.Duration
+	}{
+		{
+			name:         "success is not retried",
+			statuses:     []int{http.StatusOK},
+			policy:       noWait,
+			wantAttempts: 1,
+			wantMaxWait:  time.Second,
+		},
+		{
+			name:         "503 is retried up to max attempts",
+			statuses:     []int{http.StatusServiceUnavailable},
+			policy:       noWait,
+			wantAttempts: 3,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantMaxWait:  time.Second,
+		},
+		{
+			name:         "429 is retried until it succeeds",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK},
+			policy:       noWait,
+			wantAttempts: 3,
+			wantMaxWait:  time.Second,
+		},
+		{
+			name:         "404 is not retried",
+			statuses:     []int{http.StatusNotFound},
+			policy:       noWait,
+			wantAttempts: 1,
+			wantStatus:   http.StatusNotFound,
+			wantErr:      ErrNotFound,
+			wantMaxWait:  time.Second,
+		},
+		{
+			name:         "a single attempt is not retried",
+			statuses:     []int{http.StatusServiceUnavailable},
+			policy:       RetryPolicy{MaxAttempts: 1},
+			wantAttempts: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantMaxWait:  time.Second,
+		},
+		{
+			name:         "Retry-After is honoured",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:   "1",
+			policy:       RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Nanosecond, MaxBackoff: time.Nanosecond, MaxRetryAfter: 5 * time.Second},
+			wantAttempts: 2,
+			wantMinWait:  time.Second,
+			wantMaxWait:  3 * time.Second,
+		},
+		{
+			name:         "Retry-After is capped",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:   "3600",
+			policy:       RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Nanosecond, MaxBackoff: 10 * time.Millisecond},
+			wantAttempts: 2,
+			wantMinWait:  10 * time.Millisecond,
+			wantMaxWait:  time.Second,
+		},
+		{
+			name:         "no wait past the context deadline",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:   "5",
+			policy:       RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Nanosecond, MaxBackoff: time.Nanosecond, MaxRetryAfter: 10 * time.Second},
+			timeout:      time.Second,
+			wantAttempts: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantMaxWait:  500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &scriptedServer{statuses: tt.statuses, retryAfter: tt.retryAfter}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			c := NewClientWithOptions(srv.URL, WithAuthToken("token"), WithRetryPolicy(tt.policy)).(*client)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			r, err := newRequest(http.MethodGet, srv.URL+"/v6/datasets", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			start := time.Now()
+			_, err = c.get(ctx, opListDatasets, r)
+			elapsed := time.Since(start)
+
+			if got := s.attempts(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+
+			var ftbErr *Error
+			switch {
+			case tt.wantStatus == 0 && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantStatus != 0 && (!errors.As(err, &ftbErr) || ftbErr.StatusCode != tt.wantStatus):
+				t.Errorf("get() error = %v, want status %d", err, tt.wantStatus)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("get() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if elapsed < tt.wantMinWait || elapsed > tt.wantMaxWait {
+				t.Errorf("get() took %v, want between %v and %v", elapsed, tt.wantMinWait, tt.wantMaxWait)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package ftb
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// CrossTab is a wide cross-tabulation of a V4Table. Each row and column is identified by the codebook labels of its
+// dimensions, and each cell holds the observation for that combination, or an empty string if there is none.
+type CrossTab struct {
+	RowDimensions    []string
+	ColumnDimensions []string
+	RowHeaders       [][]string
+	ColumnHeaders    [][]string
+	Cells            [][]string
+}
+
+// Pivot returns a cross-tabulation of the table with the rows and cols dimensions across the rows and columns. Every
+// dimension with more than one option must be used in either rows or cols.
+func (o *V4Table) Pivot(rows []string, cols []string) (*CrossTab, error) {
+	rowIndices, err := o.dimensionIndices(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	colIndices, err := o.dimensionIndices(cols)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.checkPivotDimensions(append(append([]int{}, rowIndices...), colIndices...)); err != nil {
+		return nil, err
+	}
+
+	ct := &CrossTab{
+		RowDimensions:    rows,
+		ColumnDimensions: cols,
+		RowHeaders:       make([][]string, 0),
+		ColumnHeaders:    make([][]string, 0),
+		Cells:            make([][]string, 0),
+	}
+
+	rowKeys := make(map[string]int, 0)
+	colKeys := make(map[string]int, 0)
+	values := make(map[[2]int]string, 0)
+
+	for _, r := range o.Rows {
+		ri := lookupOrAdd(rowKeys, &ct.RowHeaders, r, rowIndices)
+		ci := lookupOrAdd(colKeys, &ct.ColumnHeaders, r, colIndices)
+
+		cell := [2]int{ri, ci}
+		if _, ok := values[cell]; ok {
+			return nil, fmt.Errorf("%w: more than one observation for row %s and column %s", ErrResultShapeMismatch,
+				strings.Join(ct.RowHeaders[ri], ", "), strings.Join(ct.ColumnHeaders[ci], ", "))
+		}
+		values[cell] = r[len(r)-1]
+	}
+
+	for ri := range ct.RowHeaders {
+		row := make([]string, len(ct.ColumnHeaders))
+		for ci := range ct.ColumnHeaders {
+			row[ci] = values[[2]int{ri, ci}]
+		}
+		ct.Cells = append(ct.Cells, row)
+	}
+
+	return ct, nil
+}
+
+// dimensionIndices returns the position of each named dimension in the table, where dimension i occupies the label
+// column 2i and the code column 2i+1.
+func (o *V4Table) dimensionIndices(names []string) ([]int, error) {
+	indices := make([]int, 0, len(names))
+	for _, name := range names {
+		found := -1
+		for i := 0; i*2 < len(o.Header)-1; i++ {
+			if strings.EqualFold(o.Header[i*2], name) {
+				found = i
+			}
+		}
+
+		if found < 0 {
+			return nil, fmt.Errorf("dimension %s is not in the table", name)
+		}
+		indices = append(indices, found)
+	}
+
+	return indices, nil
+}
+
+// checkPivotDimensions ensures each dimension not used in the pivot has a single option, so no observations collide.
+func (o *V4Table) checkPivotDimensions(used []int) error {
+	isUsed := make(map[int]bool, 0)
+	for _, i := range used {
+		if isUsed[i] {
+			return fmt.Errorf("dimension %s is used more than once", o.Header[i*2])
+		}
+		isUsed[i] = true
+	}
+
+	for i := 0; i*2 < len(o.Header)-1; i++ {
+		if isUsed[i] {
+			continue
+		}
+
+		codes := make(map[string]bool, 0)
+		for _, r := range o.Rows {
+			codes[r[i*2+1]] = true
+		}
+
+		if len(codes) > 1 {
+			return fmt.Errorf("dimension %s must be used in the rows or columns", o.Header[i*2])
+		}
+	}
+
+	return nil
+}
+
+// lookupOrAdd returns the position of the row's labels for the dimensions in headers, adding them if not yet seen.
+func lookupOrAdd(keys map[string]int, headers *[][]string, row []string, indices []int) int {
+	labels := make([]string, 0, len(indices))
+	codes := make([]string, 0, len(indices))
+	for _, i := range indices {
+		labels = append(labels, row[i*2])
+		codes = append(codes, row[i*2+1])
+	}
+
+	key := strings.Join(codes, "\x1f")
+	if i, ok := keys[key]; ok {
+		return i
+	}
+
+	keys[key] = len(*headers)
+	*headers = append(*headers, labels)
+	return keys[key]
+}
+
+// header returns the header row, using the row dimension names followed by the labels of each column joined with " / ".
+func (t *CrossTab) header() []string {
+	header := append([]string{}, t.RowDimensions...)
+	for _, labels := range t.ColumnHeaders {
+		header = append(header, strings.Join(labels, " / "))
+	}
+
+	return header
+}
+
+func (t *CrossTab) records() [][]string {
+	records := make([][]string, 0, len(t.Cells))
+	for i, cells := range t.Cells {
+		records = append(records, append(append([]string{}, t.RowHeaders[i]...), cells...))
+	}
+
+	return records
+}
+
+// Print writes the cross-tabulation to stdout.
+func (t *CrossTab) Print() {
+	t.Write(os.Stdout)
+}
+
+// Write renders the cross-tabulation as a text table to w.
+func (t *CrossTab) Write(w io.Writer) {
+	tw := tablewriter.NewWriter(w)
+	tw.SetAutoFormatHeaders(false)
+	tw.SetHeader(t.header())
+	tw.AppendBulk(t.records())
+	tw.Render()
+}
+
+// WriteCSV writes the cross-tabulation to w as CSV.
+func (t *CrossTab) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.header()); err != nil {
+		return err
+	}
+
+	if err := cw.WriteAll(t.records()); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
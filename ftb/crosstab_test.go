@@ -0,0 +1,129 @@
+package ftb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testV4Header = []string{"SEX", "SEX code", "AGE", "AGE code", "COUNTRY", "COUNTRY code", "Observation"}
+
+func TestPivot(t *testing.T) {
+	tests := []struct {
+		name           string
+		rows           [][]string
+		rowDims        []string
+		colDims        []string
+		wantRowHeaders [][]string
+		wantColHeaders [][]string
+		wantCells      [][]string
+		wantErr        error
+	}{
+		{
+			name: "rows and columns",
+			rows: [][]string{
+				{"Male", "1", "Age 0", "0", "Wales", "W", "10"},
+				{"Male", "1", "Age 1", "1", "Wales", "W", "11"},
+				{"Female", "2", "Age 0", "0", "Wales", "W", "20"},
+				{"Female", "2", "Age 1", "1", "Wales", "W", "21"},
+			},
+			rowDims:        []string{"sex"},
+			colDims:        []string{"AGE"},
+			wantRowHeaders: [][]string{{"Male"}, {"Female"}},
+			wantColHeaders: [][]string{{"Age 0"}, {"Age 1"}},
+			wantCells:      [][]string{{"10", "11"}, {"20", "21"}},
+		},
+		{
+			name: "missing combinations are empty",
+			rows: [][]string{
+				{"Male", "1", "Age 0", "0", "Wales", "W", "10"},
+				{"Female", "2", "Age 1", "1", "Wales", "W", "21"},
+			},
+			rowDims:        []string{"SEX"},
+			colDims:        []string{"AGE"},
+			wantRowHeaders: [][]string{{"Male"}, {"Female"}},
+			wantColHeaders: [][]string{{"Age 0"}, {"Age 1"}},
+			wantCells:      [][]string{{"10", ""}, {"", "21"}},
+		},
+		{
+			name: "options sharing a label stay separate",
+			rows: [][]string{
+				{"Male", "1", "Age 0", "0", "Wales", "W", "10"},
+				{"Male", "1", "Age 0", "00", "Wales", "W", "11"},
+			},
+			rowDims:        []string{"SEX"},
+			colDims:        []string{"AGE"},
+			wantRowHeaders: [][]string{{"Male"}},
+			wantColHeaders: [][]string{{"Age 0"}, {"Age 0"}},
+			wantCells:      [][]string{{"10", "11"}},
+		},
+		{
+			name: "duplicate observations collide",
+			rows: [][]string{
+				{"Male", "1", "Age 0", "0", "Wales", "W", "10"},
+				{"Male", "1", "Age 0", "0", "Wales", "W", "11"},
+			},
+			rowDims: []string{"SEX"},
+			colDims: []string{"AGE"},
+			wantErr: ErrResultShapeMismatch,
+		},
+		{
+			name: "unused dimension with several options is rejected",
+			rows: [][]string{
+				{"Male", "1", "Age 0", "0", "Wales", "W", "10"},
+				{"Male", "1", "Age 0", "0", "England", "E", "11"},
+			},
+			rowDims: []string{"SEX"},
+			colDims: []string{"AGE"},
+		},
+		{
+			name: "dimension used twice is rejected",
+			rows: [][]string{
+				{"Male", "1", "Age 0", "0", "Wales", "W", "10"},
+			},
+			rowDims: []string{"SEX"},
+			colDims: []string{"SEX", "AGE"},
+		},
+		{
+			name: "unknown dimension is rejected",
+			rows: [][]string{
+				{"Male", "1", "Age 0", "0", "Wales", "W", "10"},
+			},
+			rowDims: []string{"REGION"},
+			colDims: []string{"AGE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &V4Table{Header: testV4Header, Rows: tt.rows}
+
+			ct, err := table.Pivot(tt.rowDims, tt.colDims)
+			if tt.wantErr != nil || tt.wantCells == nil {
+				if err == nil {
+					t.Fatal("Pivot() error = nil, want an error")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Pivot() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(ct.RowHeaders, tt.wantRowHeaders) {
+				t.Errorf("RowHeaders = %v, want %v", ct.RowHeaders, tt.wantRowHeaders)
+			}
+
+			if !reflect.DeepEqual(ct.ColumnHeaders, tt.wantColHeaders) {
+				t.Errorf("ColumnHeaders = %v, want %v", ct.ColumnHeaders, tt.wantColHeaders)
+			}
+
+			if !reflect.DeepEqual(ct.Cells, tt.wantCells) {
+				t.Errorf("Cells = %v, want %v", ct.Cells, tt.wantCells)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package ftb
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+func (c *client) ListDatasets(ctx context.Context) (*codebook.Datasets, error) {
+	req, err := newListDatasetsReq(c.baseURL())
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := c.get(ctx, opListDatasets, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var datasets codebook.Datasets
+	err = json.Unmarshal(b, &datasets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &datasets, nil
+}
+
+func (c *client) GetDataset(ctx context.Context, name string) (*codebook.Dataset, error) {
+	req, err := newGetDatasetReq(c.baseURL(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := c.get(ctx, opGetDataset, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataset codebook.Dataset
+	err = json.Unmarshal(b, &dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataset, nil
+}
+
+// resolveRootDimension returns the rule root variable for the dataset if the query does not specify one. The rule root
+// variable is cached with the dataset codebooks so it is only requested from FTB once per dataset digest.
+func (c *client) resolveRootDimension(ctx context.Context, q Query) (string, error) {
+	if q.RootDimension != "" {
+		return q.RootDimension, nil
+	}
+
+	if name, ok := c.codebookCache.getRootVariable(q.DatasetName); ok {
+		return name, nil
+	}
+
+	dataset, err := c.GetDataset(ctx, q.DatasetName)
+	if err != nil {
+		return "", err
+	}
+
+	c.codebookCache.putRootVariable(q.DatasetName, dataset.RuleRootVariable)
+	return dataset.RuleRootVariable, nil
+}
@@ -4,29 +4,75 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"strings"
+	"sync"
 
 	"github.com/ONSdigital/dp-ftb-client-go/codebook"
 )
 
 func (c *client) GetDimension(ctx context.Context, dataset, dimension string) (*codebook.Dimension, error) {
-	req, err := newGetDimensionReq(c.Host, c.AuthToken, dataset, dimension)
+	cb, err := c.GetCodebook(ctx, dataset, dimension)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.HttpCli.Do(ctx, req)
+	details := cb.GetDimension(dimension)
+	if details == nil {
+		return nil, fmt.Errorf("%w: dimension %s not in dataset %s codebook", ErrNotFound, dimension, dataset)
+	}
+
+	return details, nil
+}
+
+// GetCodebook returns the codebook for the requested variables of a dataset in a single request. If no variables are
+// provided the codebook for every variable in the dataset is returned. Variables requested by alias are named by their
+// alias in the codebook returned.
+func (c *client) GetCodebook(ctx context.Context, dataset string, vars ...string) (*codebook.Codebook, error) {
+	names := make(aliasNames, 0)
+	resolved := make([]string, 0, len(vars))
+	for _, v := range vars {
+		variable := c.Aliases.Resolve(dataset, v)
+		if variable != v {
+			names[strings.ToUpper(variable)] = v
+		}
+		resolved = append(resolved, variable)
+	}
+
+	cb, err := c.getCodebook(ctx, dataset, resolved...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cb.CodeBook {
+		cb.CodeBook[i].Name = names.restore(cb.CodeBook[i].Name)
+	}
+
+	return cb, nil
+}
+
+// getFullCodebook returns the codebook of every dimension of the dataset, cached until the dataset digest changes. The
+// codebook is shared between callers so must not be modified.
+func (c *client) getFullCodebook(ctx context.Context, dataset string) (*codebook.Codebook, error) {
+	if cb := c.codebookCache.getCodebook(dataset); cb != nil {
+		return cb, nil
+	}
+
+	cb, err := c.getCodebook(ctx, dataset)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	c.codebookCache.putCodebook(dataset, cb)
+	return cb, nil
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("incorrect status code expected 200 but was %d", resp.StatusCode)
+func (c *client) getCodebook(ctx context.Context, dataset string, vars ...string) (*codebook.Codebook, error) {
+	req, err := newGetCodebookReq(c.baseURL(), dataset, vars...)
+	if err != nil {
+		return nil, err
 	}
 
-	b, err := ioutil.ReadAll(resp.Body)
+	b, err := c.get(ctx, opGetCodebook, req)
 	if err != nil {
 		return nil, err
 	}
@@ -37,23 +83,21 @@ func (c *client) GetDimension(ctx context.Context, dataset, dimension string) (*
 		return nil, err
 	}
 
-	return &cb.CodeBook[0], nil
+	return &cb, nil
 }
 
 func (c *client) GetDimensionByIndex(ctx context.Context, dataset, dimension string, index int) (*GetDimensionOptionResponse, error) {
-	r, err := newGetDimensionByIndexRequest(c.Host, c.AuthToken, dataset, dimension, index)
+	return c.getDimensionByIndex(ctx, dataset, c.Aliases.Resolve(dataset, dimension), index)
+}
 
-	resp, err := c.HttpCli.Do(ctx, r)
+// getDimensionByIndex returns the option at the index of a dimension named by its FTB variable.
+func (c *client) getDimensionByIndex(ctx context.Context, dataset, dimension string, index int) (*GetDimensionOptionResponse, error) {
+	r, err := newGetDimensionByIndexRequest(c.baseURL(), dataset, dimension, index)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("error status code: %d", resp.StatusCode)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := c.get(ctx, opGetDimensionByIndex, r)
 	if err != nil {
 		return nil, err
 	}
@@ -67,17 +111,75 @@ func (c *client) GetDimensionByIndex(ctx context.Context, dataset, dimension str
 	return &dim, nil
 }
 
-func (c *client) getDimensionDetails(dataset string, dims []DimensionOptions) (map[string]*codebook.Dimension, error) {
+// getDimensionDetails returns the codebook entry for each dimension, keyed on the dimension name provided. Dimensions
+// cached for the dataset digest are not requested from FTB, and the remainder are requested in batches fetched
+// concurrently. The remaining fetches are cancelled if any batch fails.
+func (c *client) getDimensionDetails(ctx context.Context, dataset, digest string, dims []DimensionOptions) (map[string]*codebook.Dimension, error) {
 	mapping := make(map[string]*codebook.Dimension, 0)
+	missing := make([]string, 0)
 
 	for _, d := range dims {
-		details, err := c.GetDimension(context.Background(), dataset, d.Name)
+		if details := c.codebookCache.get(dataset, digest, d.Name); details != nil {
+			mapping[d.Name] = details
+			continue
+		}
+		missing = append(missing, d.Name)
+	}
+
+	if len(missing) == 0 {
+		return mapping, nil
+	}
+
+	batches := batchNames(missing, c.CodebookBatchSize)
+	var mu sync.Mutex
+
+	err := forEach(ctx, len(batches), c.MaxConcurrency, func(ctx context.Context, i int) error {
+		cb, err := c.getCodebook(ctx, dataset, batches[i]...)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		batchDigest := digest
+		if batchDigest == "" {
+			batchDigest = cb.Dataset.Digest
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, name := range batches[i] {
+			details := cb.GetDimension(name)
+			if details == nil {
+				return fmt.Errorf("%w: dimension %s not in dataset %s codebook", ErrNotFound, name, dataset)
+			}
+
+			c.codebookCache.put(dataset, batchDigest, details)
+			mapping[name] = details
 		}
 
-		mapping[d.Name] = details
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return mapping, nil
 }
+
+// batchNames splits names into batches of at most size names. A size of zero or less returns a single batch.
+func batchNames(names []string, size int) [][]string {
+	if size <= 0 || size >= len(names) {
+		return [][]string{names}
+	}
+
+	batches := make([][]string, 0)
+	for start := 0; start < len(names); start += size {
+		end := start + size
+		if end > len(names) {
+			end = len(names)
+		}
+		batches = append(batches, names[start:end])
+	}
+
+	return batches
+}
@@ -0,0 +1,91 @@
+package ftb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	opListDatasets        = "ListDatasets"
+	opGetDataset          = "GetDataset"
+	opGetCodebook         = "GetCodebook"
+	opGetDimensionByIndex = "GetDimensionByIndex"
+	opQuery               = "Query"
+)
+
+var (
+	ErrNotFound            = errors.New("ftb: not found")
+	ErrUnauthorized        = errors.New("ftb: unauthorized")
+	ErrDatasetNotFound     = errors.New("ftb: dataset not found")
+	ErrResultShapeMismatch = errors.New("ftb: result shape mismatch")
+	ErrInvalidQuery        = errors.New("ftb: invalid query")
+	ErrTooManyCells        = errors.New("ftb: too many cells")
+)
+
+// datasetOperations are the operations that FTB responds to with a 404 when the dataset does not exist. A 404 from
+// GetDimensionByIndex may instead mean the dimension or index does not exist, so it is reported as ErrNotFound.
+var datasetOperations = map[string]bool{
+	opGetDataset:  true,
+	opGetCodebook: true,
+	opQuery:       true,
+}
+
+// Error is returned when FTB responds to a request with an unsuccessful status code.
+type Error struct {
+	Operation  string
+	StatusCode int
+	URL        string
+	Body       string
+	Err        error
+}
+
+func newError(operation string, r *http.Request, statusCode int, body []byte) *Error {
+	e := &Error{
+		Operation:  operation,
+		StatusCode: statusCode,
+		URL:        r.URL.String(),
+		Body:       string(body),
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized:
+		e.Err = ErrUnauthorized
+	case http.StatusNotFound:
+		if datasetOperations[operation] {
+			e.Err = ErrDatasetNotFound
+		} else {
+			e.Err = ErrNotFound
+		}
+	}
+
+	return e
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("ftb %s error: status %d from %s", e.Operation, e.StatusCode, e.URL)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports a not found error for any 404 response so callers can match ErrNotFound as well as the more specific
+// ErrDatasetNotFound.
+func (e *Error) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// ValidationError lists every problem found when validating a query.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid query: %s", strings.Join(e.Problems, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidQuery
+}
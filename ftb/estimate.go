@@ -0,0 +1,81 @@
+package ftb
+
+import (
+	"context"
+	"fmt"
+)
+
+const maxInt = int(^uint(0) >> 1)
+
+// CellLimitError is returned when a query would produce more cells than the client MaxCells option allows.
+type CellLimitError struct {
+	Estimated int
+	Max       int
+}
+
+func (e *CellLimitError) Error() string {
+	return fmt.Sprintf("query would return %d cells which exceeds the maximum of %d", e.Estimated, e.Max)
+}
+
+func (e *CellLimitError) Unwrap() error {
+	return ErrTooManyCells
+}
+
+// EstimateCells returns the number of cells the query would produce, calculated from the number of options selected for
+// each dimension. Wildcard dimensions and exclusions are sized using the dataset codebook.
+func (c *client) EstimateCells(ctx context.Context, q Query) (int, error) {
+	q, _ = c.Aliases.resolveQuery(q)
+	return c.estimateCells(ctx, q)
+}
+
+// estimateCells estimates the cells of a query whose dimension names are already resolved to FTB variables.
+func (c *client) estimateCells(ctx context.Context, q Query) (int, error) {
+	if len(q.DimensionsOptions) == 0 {
+		return 0, nil
+	}
+
+	lookup := make([]DimensionOptions, 0)
+	for _, d := range q.DimensionsOptions {
+		if len(d.Options) == 0 || len(d.Exclude) > 0 {
+			lookup = append(lookup, d)
+		}
+	}
+
+	dimensions, err := c.getDimensionDetails(ctx, q.DatasetName, "", lookup)
+	if err != nil {
+		return 0, err
+	}
+
+	cells := 1
+	for _, d := range q.DimensionsOptions {
+		n := len(resolveOptions(d, dimensions[d.Name]))
+		if n == 0 {
+			return 0, nil
+		}
+
+		if cells > maxInt/n {
+			return maxInt, nil
+		}
+		cells *= n
+	}
+
+	return cells, nil
+}
+
+// checkMaxCells rejects the query with a *CellLimitError if it would exceed the client MaxCells option.
+func (c *client) checkMaxCells(ctx context.Context, q Query) error {
+	if c.MaxCells <= 0 {
+		return nil
+	}
+
+	cells, err := c.estimateCells(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	if cells > c.MaxCells {
+		return &CellLimitError{Estimated: cells, Max: c.MaxCells}
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package ftb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+// fakeFTB serves a single dataset over the FTB API. The count of each cell is the product of the position (from one) of
+// each of its codes in the codebook, multiplied by the sum of the positions of every dimension not in the query, so
+// counts are consistent however the dataset is queried.
+type fakeFTB struct {
+	t          *testing.T
+	dataset    codebook.Dataset
+	dimensions []codebook.Dimension
+	// blocked returns the indices of the root dimension codes blocked by disclosure control for the query dimensions.
+	blocked func(dims []string) []int
+	// codebookStatus, if set, is returned for every codebook request.
+	codebookStatus int
+
+	srv      *httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+// newFakeFTB starts a fake FTB serving the People dataset. The caller must close it.
+func newFakeFTB(t *testing.T) *fakeFTB {
+	f := &fakeFTB{
+		t:       t,
+		dataset: codebook.Dataset{Name: "People", RuleRootVariable: "COUNTRY", Digest: "digest-1"},
+		dimensions: []codebook.Dimension{
+			{Name: "COUNTRY", Codes: []string{"E", "W"}, Labels: []string{"England", "Wales"}},
+			{Name: "SEX", Codes: []string{"1", "2"}, Labels: []string{"Male", "Female"}},
+			{Name: "AGE", Codes: []string{"0", "1", "2"}, Labels: []string{"Age 0", "Age 1", "Age 2"}},
+			{
+				Name:         "AGE_2CATS",
+				Codes:        []string{"A", "B"},
+				Labels:       []string{"0-1", "2"},
+				MapFrom:      []string{"AGE"},
+				MapFromCodes: []string{"A", "A", "B"},
+			},
+		},
+	}
+	f.srv = httptest.NewServer(f)
+
+	return f
+}
+
+func (f *fakeFTB) close() {
+	f.srv.Close()
+}
+
+// discardLogger drops every event so tests against the fake are not noisy.
+type discardLogger struct{}
+
+func (discardLogger) Info(ctx context.Context, event string, data map[string]interface{}) {}
+
+// client returns a client for the fake that does not retry or log unless the options provided configure it to.
+func (f *fakeFTB) client(opts ...Option) *client {
+	opts = append([]Option{WithRetryPolicy(RetryPolicy{MaxAttempts: 1}), WithLogger(discardLogger{})}, opts...)
+	return NewClientWithOptions(f.srv.URL, opts...).(*client)
+}
+
+// paths returns the path of each request received, with the query string.
+func (f *fakeFTB) paths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string{}, f.requests...)
+}
+
+func (f *fakeFTB) count(prefix string) int {
+	n := 0
+	for _, p := range f.paths() {
+		if strings.HasPrefix(p, prefix) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (f *fakeFTB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL.RequestURI())
+	f.mu.Unlock()
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v6/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "datasets" && parts[1] == f.dataset.Name:
+		f.write(w, f.dataset)
+	case len(parts) == 2 && parts[0] == "codebook" && parts[1] == f.dataset.Name:
+		f.serveCodebook(w, r)
+	case len(parts) == 2 && parts[0] == "query" && parts[1] == f.dataset.Name:
+		f.serveQuery(w, r)
+	case len(parts) == 6 && parts[0] == "datasets" && parts[1] == f.dataset.Name && parts[4] == "index":
+		f.serveIndex(w, parts[3], parts[5])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeFTB) dimension(name string) *codebook.Dimension {
+	for i := range f.dimensions {
+		if f.dimensions[i].Name == name {
+			return &f.dimensions[i]
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeFTB) serveCodebook(w http.ResponseWriter, r *http.Request) {
+	if f.codebookStatus != 0 {
+		w.WriteHeader(f.codebookStatus)
+		return
+	}
+
+	cb := codebook.Codebook{Dataset: f.dataset, CodeBook: make([]codebook.Dimension, 0)}
+
+	vars := r.URL.Query()["var"]
+	if len(vars) == 0 {
+		cb.CodeBook = f.dimensions
+	}
+
+	for _, v := range vars {
+		d := f.dimension(v)
+		if d == nil {
+			http.NotFound(w, r)
+			return
+		}
+		cb.CodeBook = append(cb.CodeBook, *d)
+	}
+
+	f.write(w, cb)
+}
+
+func (f *fakeFTB) serveIndex(w http.ResponseWriter, name, index string) {
+	d := f.dimension(name)
+	i, err := strconv.Atoi(index)
+	if d == nil || err != nil || i < 0 || i >= len(d.Codes) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	f.write(w, GetDimensionOptionResponse{Index: i, Name: d.Labels[i], Code: d.Codes[i]})
+}
+
+func (f *fakeFTB) serveQuery(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	included := make(map[string][]string, 0)
+	for _, incl := range params[includeParam] {
+		codes := strings.Split(incl, ",")
+		included[codes[0]] = codes[1:]
+	}
+
+	resp := queryResponse{DatasetDigest: f.dataset.Digest, Dimensions: make([]dimensionDetails, 0)}
+	selected := make([][]int, 0)
+	inQuery := make(map[string]bool, 0)
+
+	for _, name := range params[dimParam] {
+		d := f.dimension(name)
+		if d == nil {
+			http.NotFound(w, r)
+			return
+		}
+		inQuery[name] = true
+
+		indices := make([]int, 0)
+		pairs := make([]int, 0)
+		for i, code := range d.Codes {
+			if _, ok := included[name]; !ok {
+				indices = append(indices, i)
+				pairs = append(pairs, i, 1)
+				continue
+			}
+			for _, wanted := range included[name] {
+				if wanted == code {
+					indices = append(indices, i)
+					pairs = append(pairs, i, 1)
+				}
+			}
+		}
+
+		selected = append(selected, indices)
+		resp.Dimensions = append(resp.Dimensions, dimensionDetails{Name: name, CatOffsetLenPairs: pairs})
+	}
+
+	if f.blocked != nil {
+		for _, i := range f.blocked(params[dimParam]) {
+			resp.EvalCatOffsetLenPairs = append(resp.EvalCatOffsetLenPairs, i, 1)
+		}
+	}
+
+	if params.Get(limitParam) != "0" && !resp.BlockedByRules() {
+		resp.Counts = f.counts(selected, inQuery)
+	}
+
+	f.write(w, resp)
+}
+
+func (f *fakeFTB) counts(selected [][]int, inQuery map[string]bool) []int {
+	marginal := 1
+	for _, d := range f.dimensions {
+		if inQuery[d.Name] || len(d.MapFrom) > 0 {
+			continue
+		}
+
+		sum := 0
+		for i := range d.Codes {
+			sum += i + 1
+		}
+		marginal *= sum
+	}
+
+	counts := []int{marginal}
+	for _, indices := range selected {
+		next := make([]int, 0, len(counts)*len(indices))
+		for _, c := range counts {
+			for _, i := range indices {
+				next = append(next, c*(i+1))
+			}
+		}
+		counts = next
+	}
+
+	return counts
+}
+
+func (f *fakeFTB) write(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		f.t.Errorf("failed to marshal fake FTB response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
@@ -0,0 +1,221 @@
+package ftb
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+// RowIterator iterates the rows of a query result in V4 layout without materialising the table. The labels and codes of
+// each row are calculated from the row index, with the last dimension of the query varying fastest.
+type RowIterator struct {
+	header       []string
+	dims         []rowDimension
+	observations []int
+	index        int
+	start        int
+	end          int
+	row          []string
+	annotator    CellAnnotator
+	err          error
+}
+
+// rowDimension holds the options of a dimension with the label of each option at the same index, so labels are looked
+// up once per option rather than once per row.
+type rowDimension struct {
+	options []string
+	labels  []string
+}
+
+func newRowDimension(options []string, details *codebook.Dimension) rowDimension {
+	labels := make([]string, len(options))
+	copy(labels, options)
+
+	if details != nil {
+		byCode := make(map[string]string, len(details.Codes))
+		for i, code := range details.Codes {
+			if i < len(details.Labels) {
+				byCode[code] = details.Labels[i]
+			}
+		}
+
+		for i, code := range options {
+			if label, ok := byCode[code]; ok {
+				labels[i] = label
+			}
+		}
+	}
+
+	return rowDimension{options: options, labels: labels}
+}
+
+func newRowIterator(queryOptions []DimensionOptions, dimensions map[string]*codebook.Dimension, observations []int) *RowIterator {
+	it := &RowIterator{
+		header:       make([]string, 0),
+		dims:         make([]rowDimension, 0),
+		observations: observations,
+		index:        -1,
+	}
+
+	for _, d := range queryOptions {
+		details := dimensions[d.Name]
+		it.header = append(it.header, d.Name, d.Name+" code")
+		it.dims = append(it.dims, newRowDimension(resolveOptions(d, details), details))
+	}
+	it.header = append(it.header, "Observation")
+
+	it.end = it.Total()
+	if it.end != len(observations) {
+		it.err = fmt.Errorf("%w: expected %d observations but FTB returned %d", ErrResultShapeMismatch, it.end, len(observations))
+	}
+
+	return it
+}
+
+// page restricts the iterator to at most limit rows starting at offset. A limit of zero or less includes every row
+// after the offset.
+func (it *RowIterator) page(offset, limit int) *RowIterator {
+	total := it.Total()
+	if offset > total {
+		offset = total
+	}
+
+	it.start = offset
+	it.end = total
+	if limit > 0 && offset+limit < total {
+		it.end = offset + limit
+	}
+
+	it.index = it.start - 1
+	return it
+}
+
+// resolveOptions returns the options selected for a dimension, or every code in the codebook for a wildcard, less any
+// excluded codes.
+func resolveOptions(d DimensionOptions, details *codebook.Dimension) []string {
+	options := d.Options
+	if len(options) == 0 && details != nil {
+		options = details.Codes
+	}
+
+	if len(d.Exclude) == 0 {
+		return options
+	}
+
+	excluded := make(map[string]bool, 0)
+	for _, code := range d.Exclude {
+		excluded[code] = true
+	}
+
+	included := make([]string, 0)
+	for _, code := range options {
+		if !excluded[code] {
+			included = append(included, code)
+		}
+	}
+
+	return included
+}
+
+func (it *RowIterator) Header() []string {
+	return it.header
+}
+
+// Len returns the number of rows the iterator yields.
+func (it *RowIterator) Len() int {
+	return it.end - it.start
+}
+
+// Total returns the total number of rows in the result, regardless of any page applied.
+func (it *RowIterator) Total() int {
+	if len(it.dims) == 0 {
+		return 0
+	}
+
+	n := 1
+	for _, d := range it.dims {
+		n *= len(d.options)
+	}
+
+	return n
+}
+
+// Next advances to the next row, returning false when there are no more rows or an error has occurred.
+func (it *RowIterator) Next() bool {
+	if it.err != nil || it.index+1 >= it.end {
+		return false
+	}
+
+	it.index++
+	it.row = it.rowAt(it.index, it.row)
+	return true
+}
+
+// Row returns the current row. The slice is reused by subsequent calls to Next so must be copied to be retained.
+func (it *RowIterator) Row() []string {
+	return it.row
+}
+
+// Cell returns the observation of the current row with any markers from the client cell annotator. It returns an empty
+// cell if there is no current row, e.g. before the first call to Next.
+func (it *RowIterator) Cell() Cell {
+	if it.index < it.start || it.index >= it.end {
+		return Cell{}
+	}
+
+	cell := Cell{Value: it.observations[it.index]}
+	if it.annotator != nil {
+		cell.Markers = it.annotator(it.row, cell.Value)
+	}
+
+	return cell
+}
+
+func (it *RowIterator) Err() error {
+	return it.err
+}
+
+func (it *RowIterator) rowAt(index int, row []string) []string {
+	size := len(it.header)
+	if cap(row) < size {
+		row = make([]string, size)
+	}
+	row = row[:size]
+
+	remainder := index
+	for i := len(it.dims) - 1; i >= 0; i-- {
+		d := it.dims[i]
+		option := remainder % len(d.options)
+		remainder /= len(d.options)
+
+		row[i*2] = d.labels[option]
+		row[i*2+1] = d.options[option]
+	}
+
+	row[size-1] = strconv.Itoa(it.observations[index])
+	return row
+}
+
+// WriteCSV writes the header and all remaining rows to w as CSV.
+func (it *RowIterator) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(it.header); err != nil {
+		return err
+	}
+
+	for it.Next() {
+		if err := cw.Write(it.Row()); err != nil {
+			return err
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
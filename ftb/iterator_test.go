@@ -0,0 +1,168 @@
+package ftb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+func testDimensions() map[string]*codebook.Dimension {
+	return map[string]*codebook.Dimension{
+		"SEX": {Name: "SEX", Codes: []string{"1", "2"}, Labels: []string{"Male", "Female"}},
+		"AGE": {Name: "AGE", Codes: []string{"0", "1", "2"}, Labels: []string{"Age 0", "Age 1", "Age 2"}},
+	}
+}
+
+func TestRowIteratorRowAt(t *testing.T) {
+	tests := []struct {
+		name         string
+		queryOptions []DimensionOptions
+		observations []int
+		index        int
+		want         []string
+	}{
+		{
+			name:         "first row",
+			queryOptions: []DimensionOptions{{Name: "SEX"}, {Name: "AGE"}},
+			observations: []int{10, 11, 12, 20, 21, 22},
+			index:        0,
+			want:         []string{"Male", "1", "Age 0", "0", "10"},
+		},
+		{
+			name:         "last dimension varies fastest",
+			queryOptions: []DimensionOptions{{Name: "SEX"}, {Name: "AGE"}},
+			observations: []int{10, 11, 12, 20, 21, 22},
+			index:        2,
+			want:         []string{"Male", "1", "Age 2", "2", "12"},
+		},
+		{
+			name:         "first dimension advances after the last wraps",
+			queryOptions: []DimensionOptions{{Name: "SEX"}, {Name: "AGE"}},
+			observations: []int{10, 11, 12, 20, 21, 22},
+			index:        3,
+			want:         []string{"Female", "2", "Age 0", "0", "20"},
+		},
+		{
+			name:         "selected options keep query order",
+			queryOptions: []DimensionOptions{{Name: "SEX", Options: []string{"2"}}, {Name: "AGE", Options: []string{"2", "0"}}},
+			observations: []int{22, 20},
+			index:        1,
+			want:         []string{"Female", "2", "Age 0", "0", "20"},
+		},
+		{
+			name:         "excluded options are skipped",
+			queryOptions: []DimensionOptions{{Name: "SEX"}, {Name: "AGE", Exclude: []string{"1"}}},
+			observations: []int{10, 12, 20, 22},
+			index:        1,
+			want:         []string{"Male", "1", "Age 2", "2", "12"},
+		},
+		{
+			name:         "codes without a label are labelled with the code",
+			queryOptions: []DimensionOptions{{Name: "SEX"}, {Name: "AGE", Options: []string{"9"}}},
+			observations: []int{19, 29},
+			index:        1,
+			want:         []string{"Female", "2", "9", "9", "29"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := newRowIterator(tt.queryOptions, testDimensions(), tt.observations)
+			if err := it.Err(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := it.rowAt(tt.index, nil)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rowAt(%d) = %v, want %v", tt.index, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRowIteratorShapeMismatch(t *testing.T) {
+	it := newRowIterator([]DimensionOptions{{Name: "SEX"}, {Name: "AGE"}}, testDimensions(), []int{1, 2, 3})
+	if it.Next() {
+		t.Error("Next() = true, want false")
+	}
+
+	if err := it.Err(); !errors.Is(err, ErrResultShapeMismatch) {
+		t.Errorf("Err() = %v, want ErrResultShapeMismatch", err)
+	}
+}
+
+func TestRowIteratorPage(t *testing.T) {
+	tests := []struct {
+		name         string
+		offset       int
+		limit        int
+		wantLen      int
+		wantObserved []string
+	}{
+		{name: "first page", offset: 0, limit: 2, wantLen: 2, wantObserved: []string{"10", "11"}},
+		{name: "middle page", offset: 2, limit: 2, wantLen: 2, wantObserved: []string{"12", "20"}},
+		{name: "last page is short", offset: 4, limit: 4, wantLen: 2, wantObserved: []string{"21", "22"}},
+		{name: "zero limit returns every row after the offset", offset: 3, limit: 0, wantLen: 3, wantObserved: []string{"20", "21", "22"}},
+		{name: "offset at the end returns no rows", offset: 6, limit: 2, wantLen: 0, wantObserved: []string{}},
+		{name: "offset past the end returns no rows", offset: 10, limit: 2, wantLen: 0, wantObserved: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := newRowIterator([]DimensionOptions{{Name: "SEX"}, {Name: "AGE"}}, testDimensions(), []int{10, 11, 12, 20, 21, 22})
+			it.page(tt.offset, tt.limit)
+
+			if it.Total() != 6 {
+				t.Errorf("Total() = %d, want 6", it.Total())
+			}
+
+			if it.Len() != tt.wantLen {
+				t.Errorf("Len() = %d, want %d", it.Len(), tt.wantLen)
+			}
+
+			observed := make([]string, 0)
+			for it.Next() {
+				row := it.Row()
+				observed = append(observed, row[len(row)-1])
+			}
+
+			if err := it.Err(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(observed, tt.wantObserved) {
+				t.Errorf("observations = %v, want %v", observed, tt.wantObserved)
+			}
+		})
+	}
+}
+
+func TestRowIteratorCell(t *testing.T) {
+	it := newRowIterator([]DimensionOptions{{Name: "SEX"}}, testDimensions(), []int{0, 7})
+	it.annotator = func(row []string, value int) []CellMarker {
+		if value == 0 {
+			return []CellMarker{MarkerStructuralZero}
+		}
+		return nil
+	}
+
+	if cell := it.Cell(); !reflect.DeepEqual(cell, Cell{}) {
+		t.Errorf("Cell() before Next = %+v, want an empty cell", cell)
+	}
+
+	want := []Cell{{Value: 0, Markers: []CellMarker{MarkerStructuralZero}}, {Value: 7}}
+	got := make([]Cell, 0)
+	for it.Next() {
+		got = append(got, it.Cell())
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cells = %+v, want %+v", got, want)
+	}
+
+	if cell := it.Cell(); !reflect.DeepEqual(cell, want[1]) {
+		t.Errorf("Cell() after the last row = %+v, want %+v", cell, want[1])
+	}
+}
@@ -1,99 +1,76 @@
 package ftb
 
 import (
-	"errors"
+	"io"
 	"os"
-	"strconv"
 
-	"github.com/ONSdigital/dp-ftb-client-go/codebook"
 	"github.com/olekukonko/tablewriter"
 )
 
+// V4Table holds the rows of a result in V4 layout. Cells holds the observation of each row with any markers that apply
+// to it, in the same order as Rows.
 type V4Table struct {
 	Header []string
 	Rows   [][]string
+	Cells  []Cell
 }
 
+// Print writes the table to stdout, adding a notes column if any cell is marked.
 func (o *V4Table) Print() {
-	tw := tablewriter.NewWriter(os.Stdout)
-	tw.SetHeader(o.Header)
+	o.Write(os.Stdout)
+}
+
+// Write renders the table as a text table to w, adding a notes column if any cell is marked.
+func (o *V4Table) Write(w io.Writer) {
+	tw := tablewriter.NewWriter(w)
 
-	for _, r := range o.Rows {
-		tw.Append(r)
+	if !o.hasMarkers() {
+		tw.SetHeader(o.Header)
+		for _, r := range o.Rows {
+			tw.Append(r)
+		}
+		tw.Render()
+		return
 	}
 
+	tw.SetHeader(append(append([]string{}, o.Header...), "Notes"))
+	for i, r := range o.Rows {
+		tw.Append(append(append([]string{}, r...), o.Cells[i].notes()))
+	}
 	tw.Render()
 }
 
-func getAsV4Table(datasetName string, queryOptions []DimensionOptions, dimensions map[string]*codebook.Dimension, observations []int) (*V4Table, error) {
-	table, err := newEmptyV4Table(datasetName, queryOptions, dimensions)
-	if err != nil {
-		return nil, err
+func (o *V4Table) hasMarkers() bool {
+	if len(o.Cells) != len(o.Rows) {
+		return false
 	}
 
-	if len(table.Rows) != len(observations) {
-		return nil, errors.New("BORK")
-	}
-
-	for i, count := range observations {
-		r := table.Rows[i]
-		r = append(r, strconv.Itoa(count))
-		table.Rows[i] = r
+	for _, c := range o.Cells {
+		if len(c.Markers) > 0 {
+			return true
+		}
 	}
 
-	return table, nil
+	return false
 }
 
-// Create a new table by calculating all permutations of the dimension options provided in order
-func newEmptyV4Table(datasetName string, queryOptions []DimensionOptions, dimensions map[string]*codebook.Dimension) (*V4Table, error) {
-	header := make([]string, 0)
-	for _, d := range queryOptions {
-		header = append(header, d.Name, d.Name + " code")
+func getAsV4Table(it *RowIterator) (*V4Table, error) {
+	if err := it.Err(); err != nil {
+		return nil, err
 	}
 
-	header = append(header, "Observation")
-
-	t := &V4Table{
-		Header: header,
-		Rows:   make([][]string, 0),
+	table := &V4Table{
+		Header: it.Header(),
+		Rows:   make([][]string, 0, it.Len()),
+		Cells:  make([]Cell, 0, it.Len()),
 	}
 
-	for _, dim := range queryOptions {
-		details := dimensions[dim.Name]
-		if len(dim.Options) == 0 {
-			dim.Options = append(dim.Options, details.Codes...)
-		}
-
-		if len(t.Rows) == 0 {
-			for _, opt := range dim.Options {
-				label := details.GetLabelByCode(opt)
-				t.Rows = append(t.Rows, []string{label, opt})
-			}
-			continue
-		}
-
-		// Cant iterate and update an array at the same time.
-		// Create a new array to update and then assign to the original after the iteration.
-		update := make([][]string, 0)
-
-		for _, currentValue := range t.Rows {
-			for _, opt := range dim.Options {
-
-				// copy the current row value
-				newRow := make([]string, 0)
-				newRow = append(newRow, currentValue...)
-
-				// append the new value the row
-				label := details.GetLabelByCode(opt)
-				newRow = append(newRow, []string{label, opt}...)
-
-				// update the tracking copy.
-				update = append(update, newRow)
-			}
-		}
-
-		t.Rows = update
+	for it.Next() {
+		row := make([]string, len(it.Row()))
+		copy(row, it.Row())
+		table.Rows = append(table.Rows, row)
+		table.Cells = append(table.Cells, it.Cell())
 	}
 
-	return t, nil
+	return table, nil
 }
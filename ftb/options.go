@@ -0,0 +1,134 @@
+package ftb
+
+import (
+	"context"
+	"time"
+
+	dphttp "github.com/ONSdigital/dp-net/http"
+	"github.com/ONSdigital/log.go/log"
+)
+
+const DefaultAPIVersion = "/v6"
+
+// Option configures a client created by NewClientWithOptions.
+type Option func(c *client)
+
+// Logger records informational client events, with data describing each event keyed on field name.
+type Logger interface {
+	Info(ctx context.Context, event string, data map[string]interface{})
+}
+
+// defaultLogger records events with log.go.
+type defaultLogger struct{}
+
+func (defaultLogger) Info(ctx context.Context, event string, data map[string]interface{}) {
+	log.Event(ctx, event, log.Data(data), log.INFO)
+}
+
+// WithAuthToken sets a static auth token sent with every request.
+func WithAuthToken(authToken string) Option {
+	return WithTokenSource(StaticToken(authToken))
+}
+
+// WithTokenSource sets the source of the auth token sent with every request. The token is invalidated and requested
+// again if FTB rejects it.
+func WithTokenSource(tokens TokenSource) Option {
+	return func(c *client) {
+		c.Tokens = tokens
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to make requests to FTB. Retries are made by the client according to its
+// RetryPolicy, so httpCli should have its own retries disabled, e.g. with SetMaxRetries(0), otherwise each attempt is
+// retried again by httpCli without honouring Retry-After.
+func WithHTTPClient(httpCli dphttp.Clienter) Option {
+	return func(c *client) {
+		c.HttpCli = httpCli
+	}
+}
+
+// WithAPIVersion sets the path prefix of the FTB API, e.g. "/v6".
+func WithAPIVersion(version string) Option {
+	return func(c *client) {
+		c.APIVersion = version
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *client) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithTimeout sets a timeout applied to each individual request made to FTB, including each retry attempt.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.Timeout = timeout
+	}
+}
+
+// WithLogger sets the logger client events are recorded with. Events are recorded with log.go by default.
+func WithLogger(logger Logger) Option {
+	return func(c *client) {
+		c.Logger = logger
+	}
+}
+
+// WithDefaultLimit sets the limit used for queries that do not specify one.
+func WithDefaultLimit(limit int) Option {
+	return func(c *client) {
+		c.DefaultLimit = limit
+	}
+}
+
+// WithCodebookCache sets the TTL and maximum number of dimensions held in the codebook cache. A ttl of zero keeps entries
+// until they are evicted or the dataset digest changes, and a maxEntries of zero disables the cache.
+func WithCodebookCache(ttl time.Duration, maxEntries int) Option {
+	return func(c *client) {
+		c.codebookCache = newCodebookCache(ttl, maxEntries)
+	}
+}
+
+// WithMaxConcurrency sets the maximum number of requests a single client call makes to FTB at once.
+func WithMaxConcurrency(n int) Option {
+	return func(c *client) {
+		c.MaxConcurrency = n
+	}
+}
+
+// WithCodebookBatchSize sets the maximum number of variables requested from FTB in a single codebook request.
+func WithCodebookBatchSize(n int) Option {
+	return func(c *client) {
+		c.CodebookBatchSize = n
+	}
+}
+
+// WithMaxCells rejects queries estimated to produce more than n cells before they are sent to FTB. Status-only queries,
+// which return no counts, are not checked.
+func WithMaxCells(n int) Option {
+	return func(c *client) {
+		c.MaxCells = n
+	}
+}
+
+// WithAliasRegistry resolves the dimension names used in every client call through the registry.
+func WithAliasRegistry(aliases *AliasRegistry) Option {
+	return func(c *client) {
+		c.Aliases = aliases
+	}
+}
+
+// WithCellAnnotator sets the function used to mark the observations of query results.
+func WithCellAnnotator(annotator CellAnnotator) Option {
+	return func(c *client) {
+		c.CellAnnotator = annotator
+	}
+}
+
+// WithRetryPolicy sets how failed requests are retried. A MaxAttempts of one disables retries.
+func WithRetryPolicy(retry RetryPolicy) Option {
+	return func(c *client) {
+		c.Retry = retry
+	}
+}
@@ -3,38 +3,74 @@ package ftb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"time"
 
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
 	"github.com/ONSdigital/log.go/log"
 )
 
 const (
 	dimParam     = "dim"
 	includeParam = "incl"
+	limitParam   = "limit"
 
 	StatusOK      = "OK"
 	StatusBlocked = "Blocked"
+
+	// maxBlockedOptionLookups is the most blocked options resolved by looking up their indices individually when the
+	// root dimension codebook cannot be used.
+	maxBlockedOptionLookups = 50
 )
 
 // input
 type Query struct {
 	DatasetName       string
 	DimensionsOptions []DimensionOptions
-	RootDimension     string
-	Limit             int
+	// RootDimension is the variable disclosure control rules are evaluated on. If empty the dataset's rule root variable is used.
+	RootDimension string
+	// Limit is the maximum number of rows returned. A limit of zero uses the client default limit, if one is configured,
+	// and otherwise returns only the disclosure control status.
+	Limit int
+	// Offset is the number of rows to skip, allowing a large table to be read in pages of Limit rows. Paging is applied
+	// by the client: FTB returns every count of the query, and Limit and Offset select the rows of the result.
+	Offset int
+	// Stream skips building the V4Table so large results can be read with QueryResult.Rows without holding every row
+	// in memory.
+	Stream bool
+	// Totals lists the dimensions to add total rows for, each row totalling the dimension for a combination of the
+	// other dimensions. Totals are only added to the V4Table of the final page, after its rows, and cover the full
+	// result regardless of Limit and Offset. Total rows are not counted in TotalRows or NextOffset. Each name must be a
+	// dimension of the query, and Totals cannot be combined with Stream.
+	Totals []string
 }
 
 type DimensionOptions struct {
 	Name    string
 	Options []string
+	// Exclude removes codes from the options, or from every code of the dimension if no options are selected.
+	Exclude []string
 }
 
 // return
 type QueryResult struct {
 	DisclosureControlDetails *DisclosureControlDetails `json:"disclosure_control_details,omitempty"`
 	V4Table                  *V4Table                  `json:"observations,omitempty"`
+	Categories               []DimensionCategories     `json:"categories,omitempty"`
+	Provenance               *Provenance               `json:"provenance,omitempty"`
+	// TotalRows is the number of rows in the full result before Limit and Offset are applied, excluding any total rows.
+	TotalRows int `json:"total_rows,omitempty"`
+	// NextOffset is the offset of the next page of rows, or zero if there are no more rows.
+	NextOffset int `json:"next_offset,omitempty"`
+
+	queryOptions []DimensionOptions
+	dimensions   map[string]*codebook.Dimension
+	observations []int
+	offset       int
+	limit        int
+	annotator    CellAnnotator
 }
 
 // return
@@ -42,7 +78,18 @@ type DisclosureControlDetails struct {
 	Status         string   `bson:"status"          json:"status,omitempty"`
 	Dimension      string   `bson:"dimension"       json:"dimension,omitempty"`
 	BlockedOptions []string `bson:"blocked_options" json:"blocked_options,omitempty"`
-	BlockedCount   int      `bson:"blocked_count" json:"blocked_count,omitempty"`
+	BlockedCount   int      `bson:"blocked_count"   json:"blocked_count,omitempty"`
+	// BlockedLabels holds the label of each blocked option in the same order as BlockedOptions.
+	BlockedLabels []string `bson:"blocked_labels" json:"blocked_labels,omitempty"`
+}
+
+// Provenance records exactly which dataset version and FTB query produced a result.
+type Provenance struct {
+	DatasetName   string    `json:"dataset_name"`
+	DatasetDigest string    `json:"dataset_digest"`
+	QueryURL      string    `json:"query_url"`
+	ExecutedAt    time.Time `json:"executed_at"`
+	Host          string    `json:"host"`
 }
 
 func NewQuery(dataset, rootDim string, params map[string][]string) Query {
@@ -67,68 +114,249 @@ func NewQuery(dataset, rootDim string, params map[string][]string) Query {
 }
 
 func (c *client) Query(ctx context.Context, q Query) (*QueryResult, error) {
-	r, err := newQueryRequest(q, c.Host, c.AuthToken)
+	q, names := c.Aliases.resolveQuery(q)
+	return c.query(ctx, q, names)
+}
+
+// query runs a query whose dimension names are already resolved to FTB variables, labelling the result with the names
+// used by the caller.
+func (c *client) query(ctx context.Context, q Query, names aliasNames) (*QueryResult, error) {
+	if q.Limit == 0 {
+		q.Limit = c.DefaultLimit
+	}
+
+	q, err := c.prepareQuery(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkTotals(q); err != nil {
+		return nil, err
+	}
+
+	// Status-only queries ask FTB for no counts, so only queries that build rows are limited.
+	if q.Limit > 0 {
+		if err := c.checkMaxCells(ctx, q); err != nil {
+			return nil, err
+		}
+	}
+
+	r, err := newQueryRequest(q, c.baseURL())
+	if err != nil {
+		return nil, err
+	}
+
+	executedAt := time.Now().UTC()
 	resp, err := c.doQuery(ctx, r)
 	if err != nil {
 		return nil, err
 	}
+	c.codebookCache.observeDigest(q.DatasetName, resp.DatasetDigest)
 
 	dcStatus, err := c.getDCStatus(ctx, resp, q.DatasetName, q.RootDimension)
 	if err != nil {
 		return nil, err
 	}
+	dcStatus.Dimension = names.restore(dcStatus.Dimension)
 
 	result := &QueryResult{
 		DisclosureControlDetails: dcStatus,
 		V4Table:                  nil,
+		Provenance: &Provenance{
+			DatasetName:   q.DatasetName,
+			DatasetDigest: resp.DatasetDigest,
+			QueryURL:      r.URL.String(),
+			ExecutedAt:    executedAt,
+			Host:          c.Host,
+		},
 	}
 
 	if dcStatus.Status == StatusBlocked {
 		return result, nil
 	}
 
+	categories, err := resp.getCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	result.Categories = names.restoreCategories(categories)
+
 	if q.Limit > 0 {
-		dimensions, err := c.getDimensionDetails(q.DatasetName, q.DimensionsOptions)
+		dimensions, err := c.getDimensionDetails(ctx, q.DatasetName, resp.DatasetDigest, q.DimensionsOptions)
 		if err != nil {
 			return nil, err
 		}
-		log.Event(ctx, "getDimensionDetails completed", log.INFO)
+		c.Logger.Info(ctx, "getDimensionDetails completed", nil)
+
+		if err := checkCategories(q.DimensionsOptions, dimensions, categories); err != nil {
+			return nil, err
+		}
 
-		table, err := getAsV4Table(q.DatasetName, q.DimensionsOptions, dimensions, resp.Counts)
+		result.queryOptions, result.dimensions = names.restoreDimensions(q.DimensionsOptions, dimensions)
+		result.observations = resp.Counts
+		result.offset = q.Offset
+		result.limit = q.Limit
+		result.annotator = c.CellAnnotator
+
+		rows := result.Rows()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		result.TotalRows = rows.Total()
+		if next := q.Offset + rows.Len(); next < result.TotalRows {
+			result.NextOffset = next
+		}
+
+		if q.Stream {
+			return result, nil
+		}
+
+		table, err := getAsV4Table(rows)
 		if err != nil {
 			return nil, err
 		}
-		log.Event(ctx, "getAsV4Table completed", log.INFO)
+		c.Logger.Info(ctx, "getAsV4Table completed", nil)
 
 		result.V4Table = table
+
+		// Total rows follow the last row of the result, so they are only added to a final page that has rows.
+		if result.NextOffset == 0 && rows.Len() > 0 {
+			if err := c.addTotals(ctx, q, result); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return result, nil
 }
 
-func (c *client) doQuery(ctx context.Context, r *http.Request) (*queryResponse, error) {
-	logD := log.Data{"url": r.URL.String()}
-	log.Event(ctx, "executing FTB query request", logD, log.INFO)
+// CheckDisclosure returns only the disclosure control status of the query. FTB is asked for no counts, no codebooks are
+// requested for the result and no table is built, so the client MaxCells limit does not apply, making it suitable for
+// checking a query interactively as options are added. Codebook entries are only requested to label blocked options.
+func (c *client) CheckDisclosure(ctx context.Context, q Query) (*DisclosureControlDetails, error) {
+	q, names := c.Aliases.resolveQuery(q)
+	return c.checkDisclosure(ctx, q, names)
+}
 
-	resp, err := c.HttpCli.Do(ctx, r)
+// checkDisclosure checks a query whose dimension names are already resolved to FTB variables.
+func (c *client) checkDisclosure(ctx context.Context, q Query, names aliasNames) (*DisclosureControlDetails, error) {
+	q, resp, err := c.queryStatus(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	details, err := c.getDCStatus(ctx, resp, q.DatasetName, q.RootDimension)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		logD["status"] = resp.StatusCode
-		logD["response_body"] = string(body)
-		log.Event(ctx, "FTB query request returned failure status code", logD, log.INFO)
-		return nil, fmt.Errorf("ftb status error: %d", resp.StatusCode)
+	details.Dimension = names.restore(details.Dimension)
+	return details, nil
+}
+
+// disclosureStatus returns only whether a query whose dimension names are already resolved to FTB variables passes
+// disclosure control, without resolving the blocked options.
+func (c *client) disclosureStatus(ctx context.Context, q Query) (string, error) {
+	_, resp, err := c.queryStatus(ctx, q)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.BlockedByRules() {
+		return StatusBlocked, nil
+	}
+
+	return StatusOK, nil
+}
+
+// queryStatus sends the resolved query to FTB asking for no counts, returning the prepared query and the response.
+func (c *client) queryStatus(ctx context.Context, q Query) (Query, *queryResponse, error) {
+	q.Limit = 0
+	q.Offset = 0
+
+	q, err := c.prepareQuery(ctx, q)
+	if err != nil {
+		return q, nil, err
+	}
+
+	r, err := newQueryRequest(q, c.baseURL())
+	if err != nil {
+		return q, nil, err
+	}
+
+	resp, err := c.doQuery(ctx, r)
+	if err != nil {
+		return q, nil, err
+	}
+	c.codebookCache.observeDigest(q.DatasetName, resp.DatasetDigest)
+
+	return q, resp, nil
+}
+
+// prepareQuery resolves the root dimension and excluded options of the query.
+func (c *client) prepareQuery(ctx context.Context, q Query) (Query, error) {
+	rootDim, err := c.resolveRootDimension(ctx, q)
+	if err != nil {
+		return q, err
+	}
+	q.RootDimension = rootDim
+
+	if q.Offset < 0 {
+		return q, fmt.Errorf("%w: offset %d must not be negative", ErrInvalidQuery, q.Offset)
+	}
+
+	return c.expandExclusions(ctx, q)
+}
+
+// expandExclusions replaces the excluded codes of each dimension with the explicit list of codes to include, using the
+// dataset codebook, as FTB queries can only include options.
+func (c *client) expandExclusions(ctx context.Context, q Query) (Query, error) {
+	excluding := make([]DimensionOptions, 0)
+	for _, d := range q.DimensionsOptions {
+		if len(d.Exclude) > 0 {
+			excluding = append(excluding, d)
+		}
+	}
+
+	if len(excluding) == 0 {
+		return q, nil
+	}
+
+	dimensions, err := c.getDimensionDetails(ctx, q.DatasetName, "", excluding)
+	if err != nil {
+		return q, err
+	}
+
+	expanded := make([]DimensionOptions, 0, len(q.DimensionsOptions))
+	for _, d := range q.DimensionsOptions {
+		if len(d.Exclude) > 0 {
+			options := resolveOptions(d, dimensions[d.Name])
+			if len(options) == 0 {
+				return q, fmt.Errorf("%w: dimension %s excludes every option", ErrInvalidQuery, d.Name)
+			}
+			d = DimensionOptions{Name: d.Name, Options: options}
+		}
+		expanded = append(expanded, d)
+	}
+
+	q.DimensionsOptions = expanded
+	return q, nil
+}
+
+func (c *client) doQuery(ctx context.Context, r *http.Request) (*queryResponse, error) {
+	logD := log.Data{"url": r.URL.String()}
+	c.Logger.Info(ctx, "executing FTB query request", logD)
+
+	body, err := c.get(ctx, opQuery, r)
+	if err != nil {
+		var ftbErr *Error
+		if errors.As(err, &ftbErr) {
+			logD["status"] = ftbErr.StatusCode
+			logD["response_body"] = ftbErr.Body
+			c.Logger.Info(ctx, "FTB query request returned failure status code", logD)
+		}
+		return nil, err
 	}
 
 	var result queryResponse
@@ -137,7 +365,7 @@ func (c *client) doQuery(ctx context.Context, r *http.Request) (*queryResponse,
 		return nil, err
 	}
 
-	log.Event(ctx, "FTB query request completed successfully", logD, log.INFO)
+	c.Logger.Info(ctx, "FTB query request completed successfully", logD)
 	return &result, nil
 }
 
@@ -148,10 +376,17 @@ func (c *client) getDCStatus(ctx context.Context, resp *queryResponse, datasetNa
 			return nil, err
 		}
 
+		codes, labels, err := c.getBlockedOptions(ctx, datasetName, resp.DatasetDigest, rootDimension, blockedCodeIndices)
+		if err != nil {
+			return nil, err
+		}
+
 		return &DisclosureControlDetails{
-			Status:       StatusBlocked,
-			Dimension:    rootDimension,
-			BlockedCount: len(blockedCodeIndices),
+			Status:         StatusBlocked,
+			Dimension:      rootDimension,
+			BlockedOptions: codes,
+			BlockedLabels:  labels,
+			BlockedCount:   len(blockedCodeIndices),
 		}, nil
 	}
 
@@ -159,9 +394,85 @@ func (c *client) getDCStatus(ctx context.Context, resp *queryResponse, datasetNa
 		Status:         StatusOK,
 		Dimension:      rootDimension,
 		BlockedOptions: []string{},
+		BlockedLabels:  []string{},
 	}, nil
 }
 
+// getBlockedOptions resolves the blocked root dimension code indices to their codes and labels using the codebook,
+// falling back to looking up the indices individually if the codebook cannot be used. The fallback is skipped when more
+// than maxBlockedOptionLookups options are blocked. If the options cannot be resolved they are left empty so the blocked
+// status and count are still returned; only cancellation of the context is an error.
+func (c *client) getBlockedOptions(ctx context.Context, datasetName, digest, rootDimension string, indices []int) ([]string, []string, error) {
+	logD := log.Data{"dataset": datasetName, "dimension": rootDimension}
+
+	dimensions, err := c.getDimensionDetails(ctx, datasetName, digest, []DimensionOptions{{Name: rootDimension}})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+		logD["error"] = err.Error()
+		c.Logger.Info(ctx, "failed to get root dimension codebook for blocked options", logD)
+	} else if codes, labels, ok := blockedOptionsFromCodebook(dimensions[rootDimension], indices); ok {
+		return codes, labels, nil
+	}
+
+	if len(indices) > maxBlockedOptionLookups {
+		logD["blocked_count"] = len(indices)
+		c.Logger.Info(ctx, "too many blocked options to resolve by index", logD)
+		return []string{}, []string{}, nil
+	}
+
+	c.Logger.Info(ctx, "resolving blocked options by index", logD)
+	codes := make([]string, len(indices))
+	labels := make([]string, len(indices))
+
+	err = forEach(ctx, len(indices), c.MaxConcurrency, func(ctx context.Context, i int) error {
+		opt, err := c.getDimensionByIndex(ctx, datasetName, rootDimension, indices[i])
+		if err != nil {
+			return err
+		}
+		codes[i] = opt.Code
+		labels[i] = opt.Name
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+		logD["error"] = err.Error()
+		c.Logger.Info(ctx, "failed to resolve blocked options by index", logD)
+		return []string{}, []string{}, nil
+	}
+
+	return codes, labels, nil
+}
+
+func blockedOptionsFromCodebook(root *codebook.Dimension, indices []int) ([]string, []string, bool) {
+	if root == nil {
+		return nil, nil, false
+	}
+
+	codes := make([]string, 0, len(indices))
+	labels := make([]string, 0, len(indices))
+	for _, i := range indices {
+		if i < 0 || i >= len(root.Codes) || i >= len(root.Labels) {
+			return nil, nil, false
+		}
+		codes = append(codes, root.Codes[i])
+		labels = append(labels, root.Labels[i])
+	}
+
+	return codes, labels, true
+}
+
 func (r *QueryResult) IsBlocked() bool {
 	return r.DisclosureControlDetails.Status == StatusBlocked
 }
+
+// Rows returns a new iterator over the page of rows selected by the query limit and offset. The iterator is empty if
+// the query was blocked or no observations were requested.
+func (r *QueryResult) Rows() *RowIterator {
+	it := newRowIterator(r.queryOptions, r.dimensions, r.observations).page(r.offset, r.limit)
+	it.annotator = r.annotator
+	return it
+}
@@ -0,0 +1,193 @@
+package ftb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-ftb-client-go/codebook"
+)
+
+func TestStatusOnlyQueriesRequestNoCounts(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(c *client, q Query) (*DisclosureControlDetails, error)
+	}{
+		{
+			name: "CheckDisclosure",
+			check: func(c *client, q Query) (*DisclosureControlDetails, error) {
+				return c.CheckDisclosure(context.Background(), q)
+			},
+		},
+		{
+			name: "Query without a limit",
+			check: func(c *client, q Query) (*DisclosureControlDetails, error) {
+				result, err := c.Query(context.Background(), q)
+				if err != nil {
+					return nil, err
+				}
+				return result.DisclosureControlDetails, nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFTB(t)
+			defer f.close()
+
+			// The query would produce 12 cells, so it is only allowed because no counts are requested.
+			c := f.client(WithMaxCells(1))
+			q := Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "COUNTRY"}, {Name: "SEX"}, {Name: "AGE"}}}
+
+			details, err := tt.check(c, q)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if details.Status != StatusOK {
+				t.Errorf("Status = %s, want %s", details.Status, StatusOK)
+			}
+
+			if n := f.count("/v6/query/People?dim=COUNTRY&dim=SEX&dim=AGE&limit=0"); n != 1 {
+				t.Errorf("status-only query requests = %d, want 1 in %v", n, f.paths())
+			}
+
+			if n := f.count("/v6/codebook/"); n != 0 {
+				t.Errorf("codebook requests = %d, want 0 in %v", n, f.paths())
+			}
+		})
+	}
+}
+
+func TestQueryMaxCells(t *testing.T) {
+	f := newFakeFTB(t)
+	defer f.close()
+
+	c := f.client(WithMaxCells(11))
+	q := Query{
+		DatasetName:       "People",
+		DimensionsOptions: []DimensionOptions{{Name: "COUNTRY"}, {Name: "SEX"}, {Name: "AGE"}},
+		Limit:             1,
+	}
+
+	_, err := c.Query(context.Background(), q)
+	if !errors.Is(err, ErrTooManyCells) {
+		t.Fatalf("Query() error = %v, want ErrTooManyCells", err)
+	}
+
+	for _, p := range f.paths() {
+		if strings.HasPrefix(p, "/v6/query/") {
+			t.Errorf("query sent to FTB after exceeding MaxCells: %s", p)
+		}
+	}
+}
+
+func TestBlockedOptionsByIndex(t *testing.T) {
+	tests := []struct {
+		name        string
+		blocked     int
+		wantLookups int
+		wantOptions int
+	}{
+		{
+			name:        "blocked options are looked up by index",
+			blocked:     3,
+			wantLookups: 3,
+			wantOptions: 3,
+		},
+		{
+			name:        "blocked options are looked up up to the limit",
+			blocked:     maxBlockedOptionLookups,
+			wantLookups: maxBlockedOptionLookups,
+			wantOptions: maxBlockedOptionLookups,
+		},
+		{
+			name:    "blocked options are not looked up above the limit",
+			blocked: maxBlockedOptionLookups + 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFTB(t)
+			defer f.close()
+
+			// The codebook cannot be used, so the blocked options can only be resolved by index.
+			f.codebookStatus = http.StatusInternalServerError
+
+			country := codebook.Dimension{Name: "COUNTRY"}
+			for i := 0; i <= maxBlockedOptionLookups; i++ {
+				country.Codes = append(country.Codes, strconv.Itoa(i))
+				country.Labels = append(country.Labels, "Country "+strconv.Itoa(i))
+			}
+			f.dimensions[0] = country
+
+			f.blocked = func(dims []string) []int {
+				indices := make([]int, tt.blocked)
+				for i := range indices {
+					indices[i] = i
+				}
+				return indices
+			}
+
+			q := Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}}, RootDimension: "COUNTRY"}
+			details, err := f.client().CheckDisclosure(context.Background(), q)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if details.Status != StatusBlocked || details.BlockedCount != tt.blocked {
+				t.Errorf("Status = %s, BlockedCount = %d, want %s, %d", details.Status, details.BlockedCount, StatusBlocked, tt.blocked)
+			}
+
+			if len(details.BlockedOptions) != tt.wantOptions || len(details.BlockedLabels) != tt.wantOptions {
+				t.Errorf("blocked options = %d, labels = %d, want %d", len(details.BlockedOptions), len(details.BlockedLabels), tt.wantOptions)
+			}
+
+			if n := f.count("/v6/datasets/People/dimensions/COUNTRY/index/"); n != tt.wantLookups {
+				t.Errorf("index lookups = %d, want %d", n, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestQueryCellAnnotator(t *testing.T) {
+	f := newFakeFTB(t)
+	defer f.close()
+
+	annotator := func(row []string, value int) []CellMarker {
+		if row[1] == "2" {
+			return []CellMarker{MarkerRounded, MarkerPerturbed}
+		}
+		return nil
+	}
+
+	q := Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}}, Limit: 10}
+	result, err := f.client(WithCellAnnotator(annotator)).Query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Cell{{Value: 18}, {Value: 36, Markers: []CellMarker{MarkerRounded, MarkerPerturbed}}}
+	if !reflect.DeepEqual(result.V4Table.Cells, want) {
+		t.Errorf("Cells = %+v, want %+v", result.V4Table.Cells, want)
+	}
+
+	var buf bytes.Buffer
+	result.V4Table.Write(&buf)
+	out := buf.String()
+
+	if !strings.Contains(strings.ToUpper(out), "NOTES") {
+		t.Errorf("table has no notes column:\n%s", out)
+	}
+
+	if !strings.Contains(out, "rounded, perturbed") {
+		t.Errorf("table has no notes for the marked cell:\n%s", out)
+	}
+}
@@ -5,12 +5,13 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 )
 
-func newQueryRequest(fq Query, host, authToken string) (*http.Request, error) {
-	ftbURL, err := url.Parse(fmt.Sprintf("%s/v6/query/%s?", host, fq.DatasetName))
+// newQueryRequest creates a request for the query. Paging is applied by the client so no limit is sent for a query that
+// builds rows, but a status-only query (a limit of zero) asks FTB for no counts.
+func newQueryRequest(fq Query, baseURL string) (*http.Request, error) {
+	ftbURL, err := url.Parse(fmt.Sprintf("%s/query/%s?", baseURL, fq.DatasetName))
 	if err != nil {
 		return nil, err
 	}
@@ -34,10 +35,12 @@ func newQueryRequest(fq Query, host, authToken string) (*http.Request, error) {
 		}
 	}
 
-	q.Add("limit", strconv.Itoa(fq.Limit))
+	if fq.Limit == 0 {
+		q.Add(limitParam, "0")
+	}
 
 	ftbURL.RawQuery = q.Encode()
-	r, err := httpRequestWithAuthHeader(authToken, http.MethodGet, ftbURL.String(), nil)
+	r, err := newRequest(http.MethodGet, ftbURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -45,22 +48,38 @@ func newQueryRequest(fq Query, host, authToken string) (*http.Request, error) {
 	return r, nil
 }
 
-func newGetDimensionByIndexRequest(host, authToken, dataset, dimension string, index int) (*http.Request, error) {
-	reqURL := fmt.Sprintf("%s/v6/datasets/%s/dimensions/%s/index/%d", host, dataset, strings.ToUpper(dimension), index)
-	return httpRequestWithAuthHeader(authToken, http.MethodGet, reqURL, nil)
+func newGetDimensionByIndexRequest(baseURL, dataset, dimension string, index int) (*http.Request, error) {
+	reqURL := fmt.Sprintf("%s/datasets/%s/dimensions/%s/index/%d", baseURL, dataset, strings.ToUpper(dimension), index)
+	return newRequest(http.MethodGet, reqURL, nil)
+}
+
+// newRequest creates a request to FTB. The Authorization header is set by the client on each attempt so that retries pick
+// up refreshed tokens.
+func newRequest(method, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequest(method, url, body)
 }
 
-func httpRequestWithAuthHeader(authToken, method, url string, body io.Reader) (*http.Request, error) {
-	r, err := http.NewRequest(method, url, body)
+func newGetCodebookReq(baseURL, dataset string, vars ...string) (*http.Request, error) {
+	ftbURL, err := url.Parse(fmt.Sprintf("%s/codebook/%s", baseURL, dataset))
 	if err != nil {
 		return nil, err
 	}
 
-	r.Header.Set("Authorization", "Bearer "+authToken)
-	return r, nil
+	q := ftbURL.Query()
+	for _, v := range vars {
+		q.Add("var", strings.ToUpper(v))
+	}
+
+	ftbURL.RawQuery = q.Encode()
+	return newRequest(http.MethodGet, ftbURL.String(), nil)
+}
+
+func newListDatasetsReq(baseURL string) (*http.Request, error) {
+	ftbURL := fmt.Sprintf("%s/datasets", baseURL)
+	return newRequest(http.MethodGet, ftbURL, nil)
 }
 
-func newGetDimensionReq(host, authToken, dataset, dimension string) (*http.Request, error) {
-	ftbURL := fmt.Sprintf("%s/v6/codebook/%s?var=%s", host, dataset, dimension)
-	return httpRequestWithAuthHeader(authToken, http.MethodGet, ftbURL, nil)
+func newGetDatasetReq(baseURL, dataset string) (*http.Request, error) {
+	ftbURL := fmt.Sprintf("%s/datasets/%s", baseURL, dataset)
+	return newRequest(http.MethodGet, ftbURL, nil)
 }
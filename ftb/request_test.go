@@ -0,0 +1,42 @@
+package ftb
+
+import (
+	"testing"
+)
+
+func TestNewQueryRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{
+			name:  "status-only query asks for no counts",
+			query: Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "sex"}}},
+			want:  "http://ftb/v6/query/People?dim=SEX&limit=0",
+		},
+		{
+			name:  "paged query requests every count",
+			query: Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}}, Limit: 10, Offset: 20},
+			want:  "http://ftb/v6/query/People?dim=SEX",
+		},
+		{
+			name:  "selected options are included",
+			query: Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX", Options: []string{"1", "", "2"}}}, Limit: 1},
+			want:  "http://ftb/v6/query/People?dim=SEX&incl=SEX%2C1%2C2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newQueryRequest(tt.query, "http://ftb/v6")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := r.URL.String(); got != tt.want {
+				t.Errorf("URL = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
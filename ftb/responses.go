@@ -1,7 +1,5 @@
 package ftb
 
-import "errors"
-
 type GetDimensionOptionResponse struct {
 	Index int    `json:"index"`
 	Name  string `json:"name"`
@@ -25,21 +23,24 @@ func (r *queryResponse) BlockedByRules() bool {
 }
 
 func (r *queryResponse) getBlockedCodeIndices() ([]int, error) {
-	if len(r.EvalCatOffsetLenPairs)%2 != 0 {
-		return nil, errors.New("incorrect input")
+	ranges, err := parseOffsetLenPairs(r.EvalCatOffsetLenPairs)
+	if err != nil {
+		return nil, err
 	}
 
-	codes := make([]int, 0)
-	for i := 0; i < len(r.EvalCatOffsetLenPairs); i += 2 {
-		startIndex := r.EvalCatOffsetLenPairs[i]
-		count := r.EvalCatOffsetLenPairs[i+1]
-
-		endIndex := startIndex + count - 1
+	return DimensionCategories{Ranges: ranges}.Indices(), nil
+}
 
-		for i := startIndex; i <= endIndex; i++ {
-			codes = append(codes, i)
+func (r *queryResponse) getCategories() ([]DimensionCategories, error) {
+	categories := make([]DimensionCategories, 0, len(r.Dimensions))
+	for _, d := range r.Dimensions {
+		ranges, err := parseOffsetLenPairs(d.CatOffsetLenPairs)
+		if err != nil {
+			return nil, err
 		}
+
+		categories = append(categories, DimensionCategories{Name: d.Name, Ranges: ranges})
 	}
 
-	return codes, nil
+	return categories, nil
 }
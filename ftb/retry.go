@@ -0,0 +1,117 @@
+package ftb
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how FTB GET requests are retried after a transport error or a transient status code. Retries
+// never wait beyond the deadline of the request context. The HTTP client used by the FTB client should not retry
+// requests itself.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made including the first. Values less than 1 disable retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxRetryAfter caps the wait requested by a Retry-After header. Zero caps it at MaxBackoff.
+	MaxRetryAfter time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	MaxRetryAfter:  10 * time.Second,
+}
+
+var retryableStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// backoff returns the exponential backoff for the attempt with jitter applied, capped at MaxBackoff.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// retryAfter returns the wait requested by a Retry-After header, capped by the policy.
+func (p RetryPolicy) retryAfter(d time.Duration) time.Duration {
+	max := p.MaxRetryAfter
+	if max <= 0 {
+		max = p.MaxBackoff
+	}
+
+	if d > max {
+		return max
+	}
+
+	return d
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var ftbErr *Error
+	if errors.As(err, &ftbErr) {
+		return retryableStatusCodes[ftbErr.StatusCode]
+	}
+
+	// Anything other than an unsuccessful FTB response is a transport error.
+	return true
+}
+
+// parseRetryAfter returns the wait requested by a Retry-After header in either delay seconds or HTTP date format.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil && time.Until(t) > 0 {
+		return time.Until(t)
+	}
+
+	return 0
+}
+
+// wait blocks for d or until the context is done. Returns false if the wait would exceed the context deadline.
+func wait(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(d).After(deadline) {
+		return false
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
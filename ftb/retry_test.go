@@ -0,0 +1,62 @@
+package ftb
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{name: "first attempt waits the initial backoff", policy: policy, attempt: 1, wantMin: 50 * time.Millisecond, wantMax: 100 * time.Millisecond},
+		{name: "backoff doubles each attempt", policy: policy, attempt: 3, wantMin: 200 * time.Millisecond, wantMax: 400 * time.Millisecond},
+		{name: "backoff is capped at max backoff", policy: policy, attempt: 5, wantMin: 500 * time.Millisecond, wantMax: time.Second},
+		{name: "backoff stays capped for later attempts", policy: policy, attempt: 50, wantMin: 500 * time.Millisecond, wantMax: time.Second},
+		{name: "zero initial backoff does not wait", policy: RetryPolicy{MaxAttempts: 3}, attempt: 2, wantMin: 0, wantMax: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got := tt.policy.backoff(tt.attempt)
+				if got < tt.wantMin || got > tt.wantMax {
+					t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.wantMin, tt.wantMax)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{name: "empty", value: "", wantMin: 0, wantMax: 0},
+		{name: "delay seconds", value: "3", wantMin: 3 * time.Second, wantMax: 3 * time.Second},
+		{name: "zero seconds", value: "0", wantMin: 0, wantMax: 0},
+		{name: "negative seconds", value: "-5", wantMin: 0, wantMax: 0},
+		{name: "http date", value: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), wantMin: 58 * time.Second, wantMax: time.Minute},
+		{name: "http date in the past", value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), wantMin: 0, wantMax: 0},
+		{name: "invalid", value: "soon", wantMin: 0, wantMax: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value)
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
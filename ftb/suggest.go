@@ -0,0 +1,175 @@
+package ftb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ONSdigital/log.go/log"
+)
+
+const (
+	coarsenDistance       = 1
+	dropDimensionDistance = 2
+)
+
+// Alternative is a query close to a blocked query that passes disclosure control. Distance measures how far the
+// alternative is from the original query, with lower values being closer.
+type Alternative struct {
+	Query       Query  `json:"query"`
+	Description string `json:"description"`
+	Distance    int    `json:"distance"`
+}
+
+// SuggestAlternatives returns queries close to q that pass disclosure control, ordered closest first. Candidates are
+// built by moving dimensions to coarser classifications, moving the root dimension to a higher level geography and
+// dropping dimensions, and each is checked with a status-only FTB query. The dataset codebook used to find coarser
+// classifications is cached, so repeated suggestions for a dataset only send the candidate queries to FTB.
+func (c *client) SuggestAlternatives(ctx context.Context, q Query) ([]Alternative, error) {
+	q, names := c.Aliases.resolveQuery(q)
+
+	rootDim, err := c.resolveRootDimension(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	q.RootDimension = rootDim
+
+	graph, err := c.GetClassificationGraph(ctx, q.DatasetName)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := candidateAlternatives(graph, q, names)
+
+	var mu sync.Mutex
+	passed := make([]Alternative, 0)
+
+	err = forEach(ctx, len(candidates), c.MaxConcurrency, func(ctx context.Context, i int) error {
+		status, err := c.disclosureStatus(ctx, candidates[i].Query)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+
+			// A candidate FTB cannot evaluate is not a usable suggestion, but should not prevent the others.
+			c.Logger.Info(ctx, "alternative query check failed", log.Data{"description": candidates[i].Description, "error": err.Error()})
+			return nil
+		}
+
+		if status == StatusOK {
+			alt := candidates[i]
+			alt.Query = names.restoreQuery(alt.Query)
+
+			mu.Lock()
+			passed = append(passed, alt)
+			mu.Unlock()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(passed, func(i, j int) bool {
+		if passed[i].Distance != passed[j].Distance {
+			return passed[i].Distance < passed[j].Distance
+		}
+		return passed[i].Description < passed[j].Description
+	})
+
+	return passed, nil
+}
+
+// candidateAlternatives returns the alternatives to the resolved query q, described using the names used by the caller.
+func candidateAlternatives(graph *ClassificationGraph, q Query, names aliasNames) []Alternative {
+	candidates := make([]Alternative, 0)
+	rootInQuery := false
+
+	for _, d := range q.DimensionsOptions {
+		if strings.EqualFold(d.Name, q.RootDimension) {
+			rootInQuery = true
+		}
+
+		// Walk up the classification hierarchy so each coarser level is a candidate.
+		for _, level := range coarserLevels(graph, d.Name) {
+			alt, err := graph.Coarsen(q, d.Name, level.name)
+			if err != nil {
+				continue
+			}
+
+			candidates = append(candidates, Alternative{
+				Query:       alt,
+				Description: fmt.Sprintf("change %s to %s", names.restore(d.Name), level.name),
+				Distance:    level.depth * coarsenDistance,
+			})
+		}
+	}
+
+	if !rootInQuery {
+		for _, level := range coarserLevels(graph, q.RootDimension) {
+			alt := q
+			alt.RootDimension = level.name
+			candidates = append(candidates, Alternative{
+				Query:       alt,
+				Description: fmt.Sprintf("change root geography %s to %s", names.restore(q.RootDimension), level.name),
+				Distance:    level.depth * coarsenDistance,
+			})
+		}
+	}
+
+	if len(q.DimensionsOptions) > 1 {
+		for i, d := range q.DimensionsOptions {
+			if strings.EqualFold(d.Name, q.RootDimension) {
+				continue
+			}
+
+			alt := q
+			alt.DimensionsOptions = make([]DimensionOptions, 0, len(q.DimensionsOptions)-1)
+			alt.DimensionsOptions = append(alt.DimensionsOptions, q.DimensionsOptions[:i]...)
+			alt.DimensionsOptions = append(alt.DimensionsOptions, q.DimensionsOptions[i+1:]...)
+
+			candidates = append(candidates, Alternative{
+				Query:       alt,
+				Description: fmt.Sprintf("remove %s", names.restore(d.Name)),
+				Distance:    dropDimensionDistance,
+			})
+		}
+	}
+
+	return candidates
+}
+
+type coarserLevel struct {
+	name  string
+	depth int
+}
+
+// coarserLevels returns every classification the dimension can be coarsened to, nearest first, with the number of
+// levels each is above the dimension.
+func coarserLevels(graph *ClassificationGraph, dimension string) []coarserLevel {
+	levels := make([]coarserLevel, 0)
+	queue := []coarserLevel{{name: dimension}}
+	seen := make(map[string]bool, 0)
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range graph.Coarser(current.name) {
+			key := strings.ToUpper(next)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			level := coarserLevel{name: next, depth: current.depth + 1}
+			levels = append(levels, level)
+			queue = append(queue, level)
+		}
+	}
+
+	return levels
+}
@@ -0,0 +1,141 @@
+package ftb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCandidateAlternatives(t *testing.T) {
+	g, err := NewClassificationGraph(testClassification())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type candidate struct {
+		Description string
+		Distance    int
+		Dimensions  []DimensionOptions
+		Root        string
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		names aliasNames
+		want  []candidate
+	}{
+		{
+			name: "coarser classifications and dropped dimensions",
+			query: Query{
+				DatasetName:       "People",
+				DimensionsOptions: []DimensionOptions{{Name: "SEX"}, {Name: "AGE", Options: []string{"0", "2"}}},
+				RootDimension:     "COUNTRY",
+			},
+			want: []candidate{
+				{"change AGE to AGE_3CATS", 1, []DimensionOptions{{Name: "SEX"}, {Name: "AGE_3CATS", Options: []string{"A", "B"}}}, "COUNTRY"},
+				{"change AGE to AGE_2CATS", 2, []DimensionOptions{{Name: "SEX"}, {Name: "AGE_2CATS", Options: []string{"X"}}}, "COUNTRY"},
+				{"remove SEX", 2, []DimensionOptions{{Name: "AGE", Options: []string{"0", "2"}}}, "COUNTRY"},
+				{"remove AGE", 2, []DimensionOptions{{Name: "SEX"}}, "COUNTRY"},
+			},
+		},
+		{
+			name: "root dimension is coarsened but not dropped",
+			query: Query{
+				DatasetName:       "People",
+				DimensionsOptions: []DimensionOptions{{Name: "AGE"}, {Name: "SEX"}},
+				RootDimension:     "AGE",
+			},
+			want: []candidate{
+				{"change AGE to AGE_3CATS", 1, []DimensionOptions{{Name: "AGE_3CATS", Options: []string{}}, {Name: "SEX"}}, "AGE_3CATS"},
+				{"change AGE to AGE_2CATS", 2, []DimensionOptions{{Name: "AGE_2CATS", Options: []string{}}, {Name: "SEX"}}, "AGE_2CATS"},
+				{"remove SEX", 2, []DimensionOptions{{Name: "AGE"}}, "AGE"},
+			},
+		},
+		{
+			name: "root dimension outside the query is moved to a coarser level",
+			query: Query{
+				DatasetName:       "People",
+				DimensionsOptions: []DimensionOptions{{Name: "SEX"}},
+				RootDimension:     "AGE",
+			},
+			names: aliasNames{"AGE": "age"},
+			want: []candidate{
+				{"change root geography age to AGE_3CATS", 1, []DimensionOptions{{Name: "SEX"}}, "AGE_3CATS"},
+				{"change root geography age to AGE_2CATS", 2, []DimensionOptions{{Name: "SEX"}}, "AGE_2CATS"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]candidate, 0)
+			for _, alt := range candidateAlternatives(g, tt.query, tt.names) {
+				got = append(got, candidate{alt.Description, alt.Distance, alt.Query.DimensionsOptions, alt.Query.RootDimension})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("candidateAlternatives() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestAlternatives(t *testing.T) {
+	f := newFakeFTB(t)
+	defer f.close()
+
+	// Any query including AGE is blocked on the first country.
+	f.blocked = func(dims []string) []int {
+		for _, d := range dims {
+			if d == "AGE" {
+				return []int{0}
+			}
+		}
+		return nil
+	}
+
+	registry := NewAliasRegistry()
+	registry.Register("People", "age", "AGE")
+	c := f.client(WithAliasRegistry(registry))
+
+	q := Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}, {Name: "age"}}}
+	want := []Alternative{
+		{
+			Query:       Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}, {Name: "AGE_2CATS", Options: []string{}}}, RootDimension: "COUNTRY"},
+			Description: "change age to AGE_2CATS",
+			Distance:    1,
+		},
+		{
+			Query:       Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}}, RootDimension: "COUNTRY"},
+			Description: "remove age",
+			Distance:    2,
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := c.SuggestAlternatives(context.Background(), q)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SuggestAlternatives() = %+v, want %+v", got, want)
+		}
+	}
+
+	if n := f.count("/v6/codebook/People"); n != 1 {
+		t.Errorf("codebook requests = %d, want 1 across both suggestions in %v", n, f.paths())
+	}
+
+	if n := f.count("/v6/datasets/People/dimensions/"); n != 0 {
+		t.Errorf("blocked option lookups = %d, want 0 in %v", n, f.paths())
+	}
+
+	for _, p := range f.paths() {
+		if strings.HasPrefix(p, "/v6/query/") && !strings.HasSuffix(p, "limit=0") {
+			t.Errorf("candidate query requested counts: %s", p)
+		}
+	}
+}
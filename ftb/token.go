@@ -0,0 +1,91 @@
+package ftb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource provides the bearer token sent with each FTB request. Invalidate is called when FTB rejects a token so
+// the next call to Token can return a refreshed value.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+	Invalidate()
+}
+
+// StaticToken is a TokenSource that always returns the same token.
+type StaticToken string
+
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+func (t StaticToken) Invalidate() {}
+
+// EnvToken is a TokenSource that reads the token from the named environment variable on every request.
+type EnvToken string
+
+func (t EnvToken) Token(ctx context.Context) (string, error) {
+	token := os.Getenv(string(t))
+	if token == "" {
+		return "", fmt.Errorf("auth token environment variable %s is not set", string(t))
+	}
+
+	return token, nil
+}
+
+func (t EnvToken) Invalidate() {}
+
+// FileToken is a TokenSource that reads the token from a file, re-reading it whenever the file modification time changes
+// or the token is invalidated.
+type FileToken struct {
+	Path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+}
+
+func NewFileToken(path string) *FileToken {
+	return &FileToken{Path: path}
+}
+
+func (t *FileToken) Token(ctx context.Context) (string, error) {
+	info, err := os.Stat(t.Path)
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && info.ModTime().Equal(t.modTime) {
+		return t.token, nil
+	}
+
+	b, err := ioutil.ReadFile(t.Path)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", errors.New("auth token file is empty")
+	}
+
+	t.token = token
+	t.modTime = info.ModTime()
+	return t.token, nil
+}
+
+func (t *FileToken) Invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.token = ""
+}
@@ -0,0 +1,143 @@
+package ftb
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// rotatingToken returns "token-1" until it is invalidated, then "token-2" and so on.
+type rotatingToken struct {
+	generation    int
+	invalidations int
+}
+
+func (t *rotatingToken) Token(ctx context.Context) (string, error) {
+	return "token-" + strconv.Itoa(t.generation+1), nil
+}
+
+func (t *rotatingToken) Invalidate() {
+	t.generation++
+	t.invalidations++
+}
+
+func TestClientGetRefreshesRejectedToken(t *testing.T) {
+	tests := []struct {
+		name              string
+		statuses          []int
+		policy            RetryPolicy
+		wantAuth          []string
+		wantInvalidations int
+		wantErr           error
+	}{
+		{
+			name:              "rejected token is refreshed and the request retried once",
+			statuses:          []int{http.StatusUnauthorized, http.StatusOK},
+			policy:            RetryPolicy{MaxAttempts: 1},
+			wantAuth:          []string{"Bearer token-1", "Bearer token-2"},
+			wantInvalidations: 1,
+		},
+		{
+			name:              "refreshed token rejected again is not retried",
+			statuses:          []int{http.StatusUnauthorized},
+			policy:            RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Nanosecond, MaxBackoff: time.Nanosecond},
+			wantAuth:          []string{"Bearer token-1", "Bearer token-2"},
+			wantInvalidations: 1,
+			wantErr:           ErrUnauthorized,
+		},
+		{
+			name:              "refresh does not use a retry attempt",
+			statuses:          []int{http.StatusUnauthorized, http.StatusServiceUnavailable, http.StatusOK},
+			policy:            RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Nanosecond, MaxBackoff: time.Nanosecond},
+			wantAuth:          []string{"Bearer token-1", "Bearer token-2", "Bearer token-2"},
+			wantInvalidations: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &scriptedServer{statuses: tt.statuses}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			tokens := &rotatingToken{}
+			c := NewClientWithOptions(srv.URL, WithTokenSource(tokens), WithRetryPolicy(tt.policy)).(*client)
+
+			r, err := newRequest(http.MethodGet, srv.URL+"/v6/datasets", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = c.get(context.Background(), opListDatasets, r)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("get() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(s.auth, tt.wantAuth) {
+				t.Errorf("Authorization headers = %v, want %v", s.auth, tt.wantAuth)
+			}
+
+			if tokens.invalidations != tt.wantInvalidations {
+				t.Errorf("invalidations = %d, want %d", tokens.invalidations, tt.wantInvalidations)
+			}
+		})
+	}
+}
+
+func TestFileTokenRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ftb-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	modTime := time.Now().Add(-time.Hour)
+	write := func(token string, modTime time.Time) {
+		if err := ioutil.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	tokens := NewFileToken(path)
+	assertToken := func(step, want string) {
+		got, err := tokens.Token(context.Background())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step, err)
+		}
+		if got != want {
+			t.Errorf("%s: Token() = %q, want %q", step, got, want)
+		}
+	}
+
+	write("first", modTime)
+	assertToken("initial read", "first")
+
+	write("second", modTime)
+	assertToken("unchanged modification time uses the cached token", "first")
+
+	tokens.Invalidate()
+	assertToken("invalidated token is re-read", "second")
+
+	write("third", modTime.Add(time.Minute))
+	assertToken("changed modification time re-reads the file", "third")
+
+	write("  ", modTime.Add(2*time.Minute))
+	if _, err := tokens.Token(context.Background()); err == nil {
+		t.Error("Token() error = nil, want an error for an empty file")
+	}
+}
@@ -0,0 +1,170 @@
+package ftb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TotalCode is the pseudo-code and label given to a dimension in a total row.
+const TotalCode = "Total"
+
+// addTotals appends a total row to the table for each combination of the other dimensions of the query, for each
+// dimension listed in Query.Totals. Totals over every option of a dimension are requested from FTB by querying without
+// the dimension, so they are subject to disclosure control, and totals over a selection of options are summed from the
+// cells of the result.
+func (c *client) addTotals(ctx context.Context, q Query, result *QueryResult) error {
+	for _, name := range q.Totals {
+		index := totalsIndex(q, name)
+		if index < 0 {
+			return fmt.Errorf("%w: totals dimension %s is not in the query", ErrInvalidQuery, name)
+		}
+
+		var totals map[string]Cell
+		var err error
+
+		if len(q.DimensionsOptions[index].Options) == 0 {
+			totals, err = c.queryTotals(ctx, q, index)
+			if err != nil {
+				return err
+			}
+		} else {
+			totals = sumTotals(result, index)
+		}
+
+		appendTotalRows(result, index, totals)
+	}
+
+	return nil
+}
+
+// checkTotals rejects totals that cannot be added to the result of the query.
+func checkTotals(q Query) error {
+	if len(q.Totals) == 0 {
+		return nil
+	}
+
+	if q.Stream {
+		return fmt.Errorf("%w: totals cannot be added to a streamed query", ErrInvalidQuery)
+	}
+
+	for _, name := range q.Totals {
+		if totalsIndex(q, name) < 0 {
+			return fmt.Errorf("%w: totals dimension %s is not in the query", ErrInvalidQuery, name)
+		}
+	}
+
+	return nil
+}
+
+// totalsIndex returns the position of the named dimension in the query, or -1 if it is not in the query.
+func totalsIndex(q Query, name string) int {
+	for i, d := range q.DimensionsOptions {
+		if strings.EqualFold(d.Name, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// queryTotals requests the query without the totalled dimension from FTB, returning the total for each combination of
+// the other dimensions keyed by their codes. The map is empty if the totals are blocked by disclosure control, and nil
+// if the query has no other dimensions so the grand total must be summed from the result.
+func (c *client) queryTotals(ctx context.Context, q Query, index int) (map[string]Cell, error) {
+	if len(q.DimensionsOptions) == 1 {
+		return nil, nil
+	}
+
+	sub := q
+	sub.Totals = nil
+	// Paging is applied by the client, so the largest limit returns every row of the totals.
+	sub.Offset = 0
+	sub.Limit = maxInt
+	sub.Stream = true
+	sub.DimensionsOptions = make([]DimensionOptions, 0, len(q.DimensionsOptions)-1)
+	sub.DimensionsOptions = append(sub.DimensionsOptions, q.DimensionsOptions[:index]...)
+	sub.DimensionsOptions = append(sub.DimensionsOptions, q.DimensionsOptions[index+1:]...)
+
+	// The query is already resolved and the sub-result is not returned, so no names need restoring.
+	subResult, err := c.query(ctx, sub, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]Cell, 0)
+	if subResult.IsBlocked() {
+		return totals, nil
+	}
+
+	rows := subResult.Rows()
+	for rows.Next() {
+		totals[rowKey(rows.Row(), -1)] = rows.Cell()
+	}
+
+	return totals, rows.Err()
+}
+
+// sumTotals sums the cells of the result over the dimension at index, keyed by the codes of the other dimensions.
+func sumTotals(result *QueryResult, index int) map[string]Cell {
+	totals := make(map[string]Cell, 0)
+
+	rows := newRowIterator(result.queryOptions, result.dimensions, result.observations)
+	for rows.Next() {
+		key := rowKey(rows.Row(), index)
+		total := totals[key]
+		total.Value += rows.Cell().Value
+		totals[key] = total
+	}
+
+	return totals
+}
+
+// appendTotalRows adds a total row for each combination of the dimensions other than index, in result order. A nil
+// totals map sums the result, and a combination missing from totals is marked as blocked.
+func appendTotalRows(result *QueryResult, index int, totals map[string]Cell) {
+	if totals == nil {
+		totals = sumTotals(result, index)
+	}
+
+	table := result.V4Table
+	added := make(map[string]bool, 0)
+
+	rows := newRowIterator(result.queryOptions, result.dimensions, result.observations)
+	for rows.Next() {
+		key := rowKey(rows.Row(), index)
+		if added[key] {
+			continue
+		}
+		added[key] = true
+
+		row := make([]string, len(rows.Row()))
+		copy(row, rows.Row())
+		row[index*2] = TotalCode
+		row[index*2+1] = TotalCode
+
+		cell, ok := totals[key]
+		if ok {
+			row[len(row)-1] = strconv.Itoa(cell.Value)
+		} else {
+			row[len(row)-1] = ""
+			cell = Cell{Markers: []CellMarker{MarkerBlocked}}
+		}
+
+		table.Rows = append(table.Rows, row)
+		table.Cells = append(table.Cells, cell)
+	}
+}
+
+// rowKey joins the codes of a V4 row, skipping the dimension at index.
+func rowKey(row []string, index int) string {
+	codes := make([]string, 0)
+	for i := 0; i*2+1 < len(row)-1; i++ {
+		if i != index {
+			codes = append(codes, row[i*2+1])
+		}
+	}
+
+	return strings.Join(codes, "\x1f")
+}
@@ -0,0 +1,165 @@
+package ftb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQueryTotals(t *testing.T) {
+	// Cells not in the query are summed by the fake, so the People query below has a marginal of 3 from COUNTRY.
+	sexAge := []DimensionOptions{{Name: "SEX"}, {Name: "AGE", Options: []string{"0", "1"}}}
+	rows := [][]string{
+		{"Male", "1", "Age 0", "0", "3"},
+		{"Male", "1", "Age 1", "1", "6"},
+		{"Female", "2", "Age 0", "0", "6"},
+		{"Female", "2", "Age 1", "1", "12"},
+	}
+
+	tests := []struct {
+		name         string
+		totals       []string
+		offset       int
+		limit        int
+		blocked      func(dims []string) []int
+		wantRows     [][]string
+		wantBlocked  []bool
+		wantSubQuery bool
+	}{
+		{
+			name:   "wildcard dimension totals are requested from FTB",
+			totals: []string{"sex"},
+			limit:  10,
+			wantRows: append(append([][]string{}, rows...),
+				[]string{TotalCode, TotalCode, "Age 0", "0", "9"},
+				[]string{TotalCode, TotalCode, "Age 1", "1", "18"},
+			),
+			wantBlocked:  []bool{false, false, false, false, false, false},
+			wantSubQuery: true,
+		},
+		{
+			name:   "selected options are summed from the result",
+			totals: []string{"AGE"},
+			limit:  10,
+			wantRows: append(append([][]string{}, rows...),
+				[]string{"Male", "1", TotalCode, TotalCode, "9"},
+				[]string{"Female", "2", TotalCode, TotalCode, "18"},
+			),
+			wantBlocked: []bool{false, false, false, false, false, false},
+		},
+		{
+			name:   "totals blocked by disclosure control are marked",
+			totals: []string{"SEX"},
+			limit:  10,
+			blocked: func(dims []string) []int {
+				if len(dims) == 1 {
+					return []int{0}
+				}
+				return nil
+			},
+			wantRows: append(append([][]string{}, rows...),
+				[]string{TotalCode, TotalCode, "Age 0", "0", ""},
+				[]string{TotalCode, TotalCode, "Age 1", "1", ""},
+			),
+			wantBlocked:  []bool{false, false, false, false, true, true},
+			wantSubQuery: true,
+		},
+		{
+			name:        "totals are not added before the final page",
+			totals:      []string{"AGE"},
+			limit:       2,
+			wantRows:    rows[:2],
+			wantBlocked: []bool{false, false},
+		},
+		{
+			name:   "totals are added to the final page",
+			totals: []string{"AGE"},
+			offset: 2,
+			limit:  2,
+			wantRows: append(append([][]string{}, rows[2:]...),
+				[]string{"Male", "1", TotalCode, TotalCode, "9"},
+				[]string{"Female", "2", TotalCode, TotalCode, "18"},
+			),
+			wantBlocked: []bool{false, false, false, false},
+		},
+		{
+			name:        "totals are not added to a page past the end",
+			totals:      []string{"AGE"},
+			offset:      10,
+			limit:       2,
+			wantRows:    [][]string{},
+			wantBlocked: []bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFTB(t)
+			defer f.close()
+			f.blocked = tt.blocked
+
+			q := Query{DatasetName: "People", DimensionsOptions: sexAge, Totals: tt.totals, Offset: tt.offset, Limit: tt.limit}
+			result, err := f.client().Query(context.Background(), q)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(result.V4Table.Rows, tt.wantRows) {
+				t.Errorf("rows = %v, want %v", result.V4Table.Rows, tt.wantRows)
+			}
+
+			blocked := make([]bool, 0, len(result.V4Table.Cells))
+			for _, cell := range result.V4Table.Cells {
+				blocked = append(blocked, len(cell.Markers) == 1 && cell.Markers[0] == MarkerBlocked)
+			}
+			if !reflect.DeepEqual(blocked, tt.wantBlocked) {
+				t.Errorf("blocked cells = %v, want %v", blocked, tt.wantBlocked)
+			}
+
+			if result.TotalRows != len(rows) {
+				t.Errorf("TotalRows = %d, want %d", result.TotalRows, len(rows))
+			}
+
+			subQueries := f.count("/v6/query/People?dim=AGE&incl=AGE%2C0%2C1")
+			if tt.wantSubQuery && subQueries != 1 {
+				t.Errorf("totals sub-queries = %d, want 1 in %v", subQueries, f.paths())
+			}
+			if !tt.wantSubQuery && subQueries != 0 {
+				t.Errorf("unexpected totals sub-query in %v", f.paths())
+			}
+		})
+	}
+}
+
+func TestQueryTotalsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+	}{
+		{
+			name:  "streamed query",
+			query: Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}}, Totals: []string{"SEX"}, Limit: 10, Stream: true},
+		},
+		{
+			name:  "dimension not in the query",
+			query: Query{DatasetName: "People", DimensionsOptions: []DimensionOptions{{Name: "SEX"}}, Totals: []string{"AGE"}, Limit: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFTB(t)
+			defer f.close()
+
+			_, err := f.client().Query(context.Background(), tt.query)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Fatalf("Query() error = %v, want ErrInvalidQuery", err)
+			}
+
+			if n := f.count("/v6/query/"); n != 0 {
+				t.Errorf("queries sent to FTB = %d, want 0", n)
+			}
+		})
+	}
+}
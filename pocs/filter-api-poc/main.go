@@ -12,7 +12,6 @@ import (
 	"github.com/ONSdigital/dp-filter-api/models"
 	"github.com/ONSdigital/dp-ftb-client-go/ftb"
 	"github.com/ONSdigital/dp-ftb-client-go/pocs/filter-api-poc/filter"
-	dpHTTP "github.com/ONSdigital/dp-net/http"
 	"github.com/ONSdigital/log.go/log"
 	"github.com/gorilla/mux"
 )
@@ -21,7 +20,7 @@ var (
 	port = ":22100"
 
 	ftbHost = fmt.Sprintf("http://%s:10100", os.Getenv("EC2_IP"))
-	ftbCli  = ftb.NewClient(ftbHost, os.Getenv("AUTH_PROXY_TOKEN"), dpHTTP.DefaultClient)
+	ftbCli  = ftb.NewClientWithOptions(ftbHost, ftb.WithAuthToken(os.Getenv("AUTH_PROXY_TOKEN")))
 )
 
 type FilterStore interface {
@@ -194,18 +193,17 @@ func updateDisclosureControlStatus(ctx context.Context, f *filter.Model) error {
 	query := ftb.Query{
 		DatasetName:       f.Dataset.ID,
 		DimensionsOptions: options,
-		RootDimension:     "OA",
-		Limit:             0,
 	}
 
-	result, err := ftbCli.Query(ctx, query)
+	details, err := ftbCli.CheckDisclosure(ctx, query)
 	if err != nil {
 		return err
 	}
 
-	f.DisclosureControl.Status = result.DisclosureControlDetails.Status
-	f.DisclosureControl.Dimension = result.DisclosureControlDetails.Dimension
-	f.DisclosureControl.BlockedCount = result.DisclosureControlDetails.BlockedCount
+	f.DisclosureControl.Status = details.Status
+	f.DisclosureControl.Dimension = details.Dimension
+	f.DisclosureControl.BlockedOptions = details.BlockedOptions
+	f.DisclosureControl.BlockedCount = details.BlockedCount
 
 	return nil
 }
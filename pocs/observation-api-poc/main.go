@@ -10,7 +10,6 @@ import (
 
 	"github.com/ONSdigital/dp-api-clients-go/dataset"
 	"github.com/ONSdigital/dp-ftb-client-go/ftb"
-	dpHTTP "github.com/ONSdigital/dp-net/http"
 	"github.com/ONSdigital/dp-observation-api/models"
 	"github.com/ONSdigital/log.go/log"
 	"github.com/gorilla/mux"
@@ -20,7 +19,7 @@ var (
 	port = ":24500"
 
 	ftbHost = fmt.Sprintf("http://%s:10100", os.Getenv("EC2_IP"))
-	ftbCli  = ftb.NewClient(ftbHost, os.Getenv("AUTH_PROXY_TOKEN"), dpHTTP.DefaultClient)
+	ftbCli  = ftb.NewClientWithOptions(ftbHost, ftb.WithAuthToken(os.Getenv("AUTH_PROXY_TOKEN")))
 )
 
 // For the purposes of the POC it easier to create a new type embedding the models.ObservationsDoc and add a new DisclosureControlDetails field.
@@ -67,8 +66,8 @@ func GetObservations(w http.ResponseWriter, r *http.Request) {
 }
 
 func getObservations(ctx context.Context, datasetName string, queryParams url.Values) (*ExtendedObservationsResponse, error) {
-	// For POC purposes have to manually set the value. In the real use case we will need to be able to determine this at run time.
-	query := ftb.NewQuery(datasetName, "OA", queryParams) //synW00007765
+	// The root dimension is left empty so the client resolves it from the dataset metadata.
+	query := ftb.NewQuery(datasetName, "", queryParams)
 	result, err := ftbCli.Query(ctx, query)
 	if err != nil {
 		return nil, err